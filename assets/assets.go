@@ -0,0 +1,9 @@
+// Package assets holds the resources built into the qomoboro binary
+package assets
+
+import "embed"
+
+// Sounds contains the default pomodoro sounds under sounds/
+//
+//go:embed sounds/*.wav
+var Sounds embed.FS
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"qomoboro/internal/cli"
+	"qomoboro/internal/models"
+	"qomoboro/internal/stats"
+	"qomoboro/internal/storage"
+)
+
+// editOptions are the changes given to edit as flags
+type editOptions struct {
+	changes []func(*models.Task)
+	hour    *string // Checked against the schedule when the command runs
+	editor  bool
+}
+
+// editCommand changes the fields of an existing task
+func editCommand(env *cliEnv) *cli.Command {
+	var opts editOptions
+	cmd := &cli.Command{
+		Name:    "edit",
+		Args:    "[id|title]",
+		Summary: "Change a task's title, description, scores, tags or schedule",
+		Help: `Changes the fields given as flags and leaves the others as they are.
+Without flags, or with --editor, opens the task as YAML in $VISUAL or
+$EDITOR and applies what is saved. An empty value clears --description,
+--tags and --hour, "none" clears --due and 0 clears --estimate.`,
+		Examples: []string{
+			`edit 12 --title "Fix API memory leak" --work 5`,
+			"edit bug --tags api,urgent --due fri",
+			"edit 12 --hour '' --estimate 0    # Unschedule",
+			"edit 12    # Open it in $EDITOR",
+		},
+		Run: func(args []string) error { return handleEditTask(env.store, opts, args) },
+	}
+
+	change := func(apply func(*models.Task)) {
+		opts.changes = append(opts.changes, apply)
+	}
+	fs := cmd.Flags()
+	fs.Func("title", "New `text` of the title", func(value string) error {
+		title := strings.TrimSpace(value)
+		if title == "" {
+			return errors.New("task title must not be empty")
+		}
+		change(func(task *models.Task) { task.Title = title })
+		return nil
+	})
+	fs.Func("description", "New `text` of the description", func(value string) error {
+		change(func(task *models.Task) { task.Description = value })
+		return nil
+	})
+	for _, score := range []struct {
+		name  string
+		field func(*models.Task) *int
+	}{
+		{"work", func(task *models.Task) *int { return &task.Score.Work }},
+		{"play", func(task *models.Task) *int { return &task.Score.Play }},
+		{"learn", func(task *models.Task) *int { return &task.Score.Learn }},
+	} {
+		fs.Func(score.name, strings.ToUpper(score.name[:1])+score.name[1:]+" `score` (0-5)", func(value string) error {
+			n, err := parseScore(value)
+			if err != nil {
+				return err
+			}
+			change(func(task *models.Task) { *score.field(task) = n })
+			return nil
+		})
+	}
+	fs.Func("tags", "Comma-separated `list` of tags replacing the current ones", func(value string) error {
+		tags := splitTags(value)
+		change(func(task *models.Task) { task.Tags = tags })
+		return nil
+	})
+	fs.Func("hour", "Canonical hour to work in, by `name`", func(value string) error {
+		opts.hour = &value
+		return nil
+	})
+	fs.Func("due", "Due `date`: today, tomorrow, a weekday, +3d, YYYY-MM-DD or none", func(value string) error {
+		if strings.EqualFold(value, "none") {
+			change(func(task *models.Task) { task.DueDate = nil })
+			return nil
+		}
+		due, err := models.ParseDate(value, time.Now())
+		if err != nil {
+			return err
+		}
+		change(func(task *models.Task) { task.DueDate = &due })
+		return nil
+	})
+	fs.Func("estimate", "Estimated `duration`, e.g. 45m or 2h", func(value string) error {
+		estimate, err := parseEstimate(value)
+		if err != nil {
+			return err
+		}
+		change(func(task *models.Task) { task.EstimatedDuration = estimate })
+		return nil
+	})
+	fs.BoolVar(&opts.editor, "editor", false, "Edit the task as YAML in $VISUAL or $EDITOR")
+	fs.BoolVar(&opts.editor, "e", false, "Edit the task as YAML in $VISUAL or $EDITOR")
+	return cmd
+}
+
+func handleEditTask(store storage.Storage, opts editOptions, args []string) error {
+	hasFlags := len(opts.changes) > 0 || opts.hour != nil
+	if hasFlags && opts.editor {
+		return cli.Usagef("give the changes either as flags or with --editor")
+	}
+
+	tasks, err := store.ListTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+	if len(tasks) == 0 {
+		fmt.Println("📝 No tasks to edit")
+		return nil
+	}
+	task, err := selectTask(tasks, args, "", "edit")
+	if err != nil {
+		return err
+	}
+
+	if hasFlags {
+		for _, apply := range opts.changes {
+			apply(task)
+		}
+		if opts.hour != nil {
+			task.CanonicalHour = ""
+			if *opts.hour != "" {
+				if task.CanonicalHour, err = canonicalHourName(store, *opts.hour); err != nil {
+					return err
+				}
+			}
+		}
+	} else {
+		changed, err := editInEditor(store, task)
+		if err != nil || !changed {
+			return err
+		}
+	}
+
+	if err := store.UpdateTask(task); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	// Scores count on the day a task was completed, not just today
+	refreshStats(store, stats.TaskDays(task, time.Now())...)
+
+	fmt.Printf("✏️  Updated: %s %s\n", task.Ref(), task.Title)
+	fmt.Printf("   Scores: Work %d, Play %d, Learn %d\n", task.Score.Work, task.Score.Play, task.Score.Learn)
+	if details := taskDetails(task, time.Now()); details != "" {
+		fmt.Printf("   %s\n", colorize(details, "dim"))
+	}
+	return nil
+}
+
+// parseEstimate parses a non-negative estimated duration
+func parseEstimate(value string) (time.Duration, error) {
+	if value == "" || value == "0" {
+		return 0, nil
+	}
+	estimate, err := time.ParseDuration(value)
+	if err != nil || estimate < 0 {
+		return 0, fmt.Errorf("invalid estimate %q, want a duration such as 45m or 2h", value)
+	}
+	return estimate, nil
+}
+
+// taskDocument is the part of a task that edit opens in the editor
+type taskDocument struct {
+	Title       string       `yaml:"title"`
+	Description string       `yaml:"description"`
+	Score       models.Score `yaml:"score"`
+	Tags        []string     `yaml:"tags"`
+	Hour        string       `yaml:"canonical_hour"`
+	Due         string       `yaml:"due_date"`           // YYYY-MM-DD, or empty
+	Estimate    string       `yaml:"estimated_duration"` // e.g. 45m, or empty
+}
+
+// editInEditor opens task as YAML in the user's editor and applies the
+// saved document to it, reporting whether anything changed. The file is
+// kept when it cannot be applied so the edits are not lost.
+func editInEditor(store storage.Storage, task *models.Task) (bool, error) {
+	doc := taskDocument{
+		Title:       task.Title,
+		Description: task.Description,
+		Score:       task.Score,
+		Tags:        task.Tags,
+		Hour:        task.CanonicalHour,
+	}
+	if doc.Tags == nil {
+		doc.Tags = []string{}
+	}
+	if task.DueDate != nil {
+		doc.Due = task.DueDate.Format("2006-01-02")
+	}
+	if task.EstimatedDuration > 0 {
+		doc.Estimate = formatDuration(task.EstimatedDuration)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Editing %s. Save and quit to apply, or empty the file to cancel.\n", task.Ref())
+	fmt.Fprintf(&buf, "# due_date takes YYYY-MM-DD, tomorrow, fri or +3d; estimated_duration takes 45m or 2h.\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return false, fmt.Errorf("failed to encode task: %w", err)
+	}
+	enc.Close()
+
+	file, err := os.CreateTemp("", "qomoboro-task-*.yaml")
+	if err != nil {
+		return false, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	path := file.Name()
+	_, err = file.Write(buf.Bytes())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return false, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	if err := runEditor(path); err != nil {
+		os.Remove(path)
+		return false, err
+	}
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read edited task: %w", err)
+	}
+	if bytes.Equal(edited, buf.Bytes()) {
+		os.Remove(path)
+		fmt.Println("📝 No changes")
+		return false, nil
+	}
+
+	var updated taskDocument
+	dec := yaml.NewDecoder(bytes.NewReader(edited))
+	dec.KnownFields(true)
+	if err := dec.Decode(&updated); errors.Is(err, io.EOF) {
+		os.Remove(path)
+		fmt.Println("❌ Cancelled")
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("invalid task YAML: %w; your edits are in %s", err, path)
+	}
+	if err := applyTaskDocument(store, task, updated); err != nil {
+		return false, fmt.Errorf("%w; your edits are in %s", err, path)
+	}
+	os.Remove(path)
+	return true, nil
+}
+
+// applyTaskDocument validates doc and copies it to task
+func applyTaskDocument(store storage.Storage, task *models.Task, doc taskDocument) error {
+	title := strings.TrimSpace(doc.Title)
+	if title == "" {
+		return errors.New("task title must not be empty")
+	}
+	for _, n := range []int{doc.Score.Work, doc.Score.Play, doc.Score.Learn} {
+		if n < 0 || n > 5 {
+			return fmt.Errorf("invalid score %d, want a whole number from 0 to 5", n)
+		}
+	}
+
+	var due *time.Time
+	if doc.Due != "" {
+		date, err := models.ParseDate(doc.Due, time.Now())
+		if err != nil {
+			return err
+		}
+		due = &date
+	}
+	estimate, err := parseEstimate(doc.Estimate)
+	if err != nil {
+		return err
+	}
+	var hour string
+	if doc.Hour != "" {
+		if hour, err = canonicalHourName(store, doc.Hour); err != nil {
+			return err
+		}
+	}
+
+	var tags []string
+	for _, tag := range doc.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	task.Title = title
+	task.Description = strings.TrimRight(doc.Description, "\n")
+	task.Score = doc.Score
+	task.Tags = tags
+	task.CanonicalHour = hour
+	task.DueDate = due
+	task.EstimatedDuration = estimate
+	return nil
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, or vi when neither is set,
+// and waits for it to exit
+func runEditor(path string) error {
+	editor := strings.TrimSpace(os.Getenv("VISUAL"))
+	if editor == "" {
+		editor = strings.TrimSpace(os.Getenv("EDITOR"))
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor may be given with arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run editor %q: %w", editor, err)
+	}
+	return nil
+}
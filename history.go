@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"qomoboro/internal/cli"
+	"qomoboro/internal/models"
+	"qomoboro/internal/output"
+	"qomoboro/internal/query"
+	"qomoboro/internal/storage"
+)
+
+// historyOptions narrow down the events history shows
+type historyOptions struct {
+	format output.Format
+	from   time.Time // Zero for no lower bound
+	to     time.Time // Zero for no upper bound
+	limit  int
+}
+
+// historyCommand shows the recorded changes to tasks
+func historyCommand(env *cliEnv) *cli.Command {
+	var opts historyOptions
+	cmd := &cli.Command{
+		Name:    "history",
+		Args:    "[id|title]",
+		Summary: "Show when tasks were created, changed and deleted",
+		Help: `Every create, update and delete is recorded with the fields it changed
+and what made it: the command, such as "cli edit", or "tui". Without a
+task, shows the changes to all tasks; deleted tasks can still be given by
+ID or title. --since and --until take today, yesterday, this-week,
+last-week, this-month, last-month, this-year or a date.`,
+		Examples: []string{
+			"history 12",
+			"history --since this-week",
+			`history "memory leak" -o json`,
+		},
+		Run: func(args []string) error { return handleHistory(env.store, opts, args) },
+	}
+
+	fs := cmd.Flags()
+	fs.Func("since", "Only changes from the start of `period`", func(value string) error {
+		start, _, err := query.Period(value, time.Now())
+		opts.from = start
+		return err
+	})
+	fs.Func("until", "Only changes up to the end of `period`", func(value string) error {
+		_, end, err := query.Period(value, time.Now())
+		opts.to = end
+		return err
+	})
+	fs.IntVar(&opts.limit, "limit", 0, "Show only the latest `N` changes")
+	outputFlag(fs, &opts.format)
+	return cmd
+}
+
+func handleHistory(store storage.Storage, opts historyOptions, args []string) error {
+	if opts.limit < 0 {
+		return cli.Usagef("--limit must not be negative")
+	}
+	filter := storage.EventFilter{From: opts.from, To: opts.to}
+
+	var task *models.Task
+	if len(args) > 0 {
+		candidates, err := historyCandidates(store)
+		if err != nil {
+			return err
+		}
+		if task, err = selectTask(candidates, args, "", "show the history of"); err != nil {
+			return err
+		}
+		filter.TaskID = task.ID
+	}
+
+	events, err := store.ListEvents(filter)
+	if err != nil {
+		return fmt.Errorf("failed to load task history: %w", err)
+	}
+	if opts.limit > 0 && len(events) > opts.limit {
+		events = events[len(events)-opts.limit:]
+	}
+
+	if !opts.format.IsText() {
+		if events == nil {
+			events = []*models.TaskEvent{}
+		}
+		return output.Write(os.Stdout, opts.format, events, eventTable(events))
+	}
+
+	if len(events) == 0 {
+		if task != nil {
+			fmt.Printf("📜 No changes recorded for %s %s\n", task.Ref(), task.Title)
+		} else {
+			fmt.Println("📜 No changes recorded")
+		}
+		return nil
+	}
+	if task != nil {
+		fmt.Printf("📜 History of %s %s:\n", task.Ref(), task.Title)
+	} else {
+		fmt.Println("📜 Task history:")
+	}
+	for _, event := range events {
+		printEvent(event, "")
+	}
+	return nil
+}
+
+// historyCandidates returns the tasks history can be asked about: those
+// in storage and those only left in the history because they were deleted
+func historyCandidates(store storage.Storage) ([]*models.Task, error) {
+	tasks, err := store.ListTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+	events, err := store.ListEvents(storage.EventFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task history: %w", err)
+	}
+
+	known := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		known[task.ID] = true
+	}
+	deleted := make(map[string]*models.Task)
+	var order []string
+	for _, event := range events {
+		if known[event.TaskID] {
+			continue
+		}
+		if deleted[event.TaskID] == nil {
+			order = append(order, event.TaskID)
+		}
+		deleted[event.TaskID] = &models.Task{
+			ID:      event.TaskID,
+			ShortID: event.ShortID,
+			Title:   event.Title + " (deleted)",
+		}
+	}
+	for _, id := range order {
+		tasks = append(tasks, deleted[id])
+	}
+	return tasks, nil
+}
+
+// printEvent writes one event, with the fields an update changed, each
+// line starting with indent
+func printEvent(event *models.TaskEvent, indent string) {
+	emoji, verb := "✏️ ", "Updated"
+	switch event.Action {
+	case models.EventCreate:
+		emoji, verb = "➕", "Created"
+	case models.EventDelete:
+		emoji, verb = "🗑️ ", "Deleted"
+	}
+
+	line := fmt.Sprintf("%s%s  %s %s #%d %s", indent, event.At.Local().Format("Mon Jan 2 15:04"), emoji, verb, event.ShortID, event.Title)
+	if event.Source != "" {
+		line += colorize("  ("+event.Source+")", "dim")
+	}
+	fmt.Println(line)
+
+	if event.Action != models.EventUpdate {
+		return
+	}
+	for _, change := range event.Changes {
+		fmt.Printf("%s   %-20s %s → %s\n", indent, change.Field+":",
+			formatEventValue(change.Field, change.Old), formatEventValue(change.Field, change.New))
+	}
+}
+
+// formatEventValue renders a field value as recorded in the task's JSON
+func formatEventValue(field string, value any) string {
+	switch v := value.(type) {
+	case nil:
+		return colorize("none", "dim")
+	case float64:
+		switch field {
+		case "status":
+			return models.TaskStatus(v).String()
+		case "estimated_duration", "actual_duration":
+			return formatDuration(time.Duration(v))
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return formatLocalTime(&t)
+		}
+		if runes := []rune(v); len(runes) > 40 {
+			v = string(runes[:37]) + "..."
+		}
+		return strconv.Quote(v)
+	case []any:
+		var values []string
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok && len(v) == 1 {
+				return "1 entry"
+			} else if !ok {
+				return fmt.Sprintf("%d entries", len(v))
+			}
+			values = append(values, s)
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
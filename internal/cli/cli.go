@@ -0,0 +1,328 @@
+// Package cli runs commands with their own flags, generated help and
+// errors that set the exit code.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Command is a command of an App, or a subcommand of another command
+type Command struct {
+	Name     string
+	Aliases  []string
+	Args     string   // Positional arguments for the usage line, e.g. "<title> [description]"
+	Summary  string   // One line for command lists
+	Help     string   // Longer description for the command's own help
+	Examples []string // Command lines without the program name, optionally followed by "# comment"
+
+	// Run runs the command with its positional arguments once its flags,
+	// which may come anywhere after the command name, are parsed
+	Run      func(args []string) error
+	Commands []*Command
+
+	flags *flag.FlagSet
+}
+
+// Flags returns the flag set of the command for defining its flags.
+// Usage strings may name the value in backquotes, e.g. "`N` rounds".
+func (c *Command) Flags() *flag.FlagSet {
+	if c.flags == nil {
+		c.flags = newFlagSet(c.Name)
+	}
+	return c.flags
+}
+
+// matches reports whether name selects the command
+func (c *Command) matches(name string) bool {
+	if strings.EqualFold(c.Name, name) {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// App is a command line program made of commands. Global flags come before
+// the command name and are also accepted among the command's own flags.
+type App struct {
+	Name     string
+	Version  string
+	Header   string // Printed above the app help, e.g. a banner
+	Footer   string // Printed below the command list in the app help
+	Commands []*Command
+
+	// Before runs after flags are parsed and before any command, e.g. to
+	// open the storage selected by global flags. It is given the path of
+	// the command, such as "backup list", or "" before Default. It does not
+	// run for help and version.
+	Before func(path string) error
+	// Default runs when no command is given; the app help is shown when nil
+	Default func() error
+
+	Stdout io.Writer // Help and version output, os.Stdout when nil
+	Stderr io.Writer // Errors reported by Execute, os.Stderr when nil
+
+	flags       *flag.FlagSet
+	showVersion bool
+}
+
+// Flags returns the global flag set of the app for defining global flags
+func (a *App) Flags() *flag.FlagSet {
+	if a.flags == nil {
+		a.flags = newFlagSet(a.Name)
+		if a.Version != "" {
+			a.flags.BoolVar(&a.showVersion, "version", false, "Show version information")
+			a.flags.BoolVar(&a.showVersion, "v", false, "Show version information")
+		}
+	}
+	return a.flags
+}
+
+// UsageError is a mistake in how the program was invoked, such as an
+// unknown flag, a missing argument or an out of range value
+type UsageError struct {
+	Command string // Path of the command, e.g. "pomo interrupt"; empty for the app
+	Err     error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// Usagef returns a UsageError for the running command
+func Usagef(format string, args ...any) error {
+	return &UsageError{Err: fmt.Errorf(format, args...)}
+}
+
+// NoArgs adapts run to a Command.Run that rejects positional arguments
+func NoArgs(run func() error) func(args []string) error {
+	return func(args []string) error {
+		if len(args) > 0 {
+			return Usagef("unexpected argument %q", args[0])
+		}
+		return run()
+	}
+}
+
+// Execute runs the app with args and reports any error, returning the exit
+// code for the process: 0 on success, 2 for usage errors and 1 otherwise
+func (a *App) Execute(args []string) int {
+	err := a.Run(args)
+	if err == nil {
+		return 0
+	}
+
+	fmt.Fprintf(a.stderr(), "❌ %v\n", err)
+	var usage *UsageError
+	if errors.As(err, &usage) {
+		fmt.Fprintf(a.stderr(), "Run '%s' for usage.\n", strings.TrimSpace(a.Name+" help "+usage.Command))
+		return 2
+	}
+	return 1
+}
+
+// Run parses the global flags in args and runs the command they name
+func (a *App) Run(args []string) error {
+	global := a.Flags()
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			a.PrintHelp()
+			return nil
+		}
+		return &UsageError{Err: flagError(err)}
+	}
+	if a.showVersion {
+		fmt.Fprintf(a.stdout(), "%s %s\n", a.Name, a.Version)
+		return nil
+	}
+
+	args = global.Args()
+	if len(args) == 0 {
+		if err := a.before(""); err != nil {
+			return err
+		}
+		if a.Default == nil {
+			a.PrintHelp()
+			return nil
+		}
+		return a.Default()
+	}
+	if strings.EqualFold(args[0], "help") {
+		return a.help(args[1:])
+	}
+
+	path, cmd, args, err := a.find(args)
+	if err != nil {
+		return err
+	}
+
+	args, err = parseInterspersed(a.commandFlags(path, cmd), args)
+	if errors.Is(err, flag.ErrHelp) {
+		a.PrintCommandHelp(path, cmd)
+		return nil
+	}
+	if err != nil {
+		return &UsageError{Command: path, Err: flagError(err)}
+	}
+	if a.showVersion {
+		fmt.Fprintf(a.stdout(), "%s %s\n", a.Name, a.Version)
+		return nil
+	}
+	if cmd.Run == nil {
+		if len(args) > 0 {
+			return &UsageError{Command: path, Err: fmt.Errorf("unknown command %q", path+" "+args[0])}
+		}
+		return &UsageError{Command: path, Err: fmt.Errorf("%s needs a subcommand", path)}
+	}
+
+	if err := a.before(path); err != nil {
+		return err
+	}
+	err = cmd.Run(args)
+	var usage *UsageError
+	if errors.As(err, &usage) && usage.Command == "" {
+		usage.Command = path
+	}
+	return err
+}
+
+// help shows the help of the command named by args, or of the app
+func (a *App) help(args []string) error {
+	if len(args) == 0 {
+		a.PrintHelp()
+		return nil
+	}
+	path, cmd, rest, err := a.find(args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return &UsageError{Command: path, Err: fmt.Errorf("unknown command %q", path+" "+rest[0])}
+	}
+	a.PrintCommandHelp(path, cmd)
+	return nil
+}
+
+// find returns the command named at the start of args with its path and
+// the arguments following its name
+func (a *App) find(args []string) (string, *Command, []string, error) {
+	var cmd *Command
+	for _, c := range a.Commands {
+		if c.matches(args[0]) {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		return "", nil, nil, &UsageError{Err: fmt.Errorf("unknown command %q", args[0])}
+	}
+
+	path := cmd.Name
+	args = args[1:]
+	for len(args) > 0 {
+		var sub *Command
+		for _, c := range cmd.Commands {
+			if c.matches(args[0]) {
+				sub = c
+			}
+		}
+		if sub == nil {
+			break
+		}
+		cmd, path, args = sub, path+" "+sub.Name, args[1:]
+	}
+	return path, cmd, args, nil
+}
+
+// commandFlags returns a flag set holding the flags of cmd followed by the
+// global flags it does not redefine
+func (a *App) commandFlags(path string, cmd *Command) *flag.FlagSet {
+	fs := newFlagSet(path)
+	cmd.Flags().VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	a.Flags().VisitAll(func(f *flag.Flag) {
+		if fs.Lookup(f.Name) == nil {
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	return fs
+}
+
+func (a *App) before(path string) error {
+	if a.Before == nil {
+		return nil
+	}
+	return a.Before(path)
+}
+
+func (a *App) stdout() io.Writer {
+	if a.Stdout != nil {
+		return a.Stdout
+	}
+	return os.Stdout
+}
+
+func (a *App) stderr() io.Writer {
+	if a.Stderr != nil {
+		return a.Stderr
+	}
+	return os.Stderr
+}
+
+// newFlagSet returns a flag set that reports errors to its caller instead
+// of printing them
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// flagError rewrites an error from the flag package to spell long flags
+// with two dashes, as the help does
+func flagError(err error) error {
+	msg := err.Error()
+	for _, prefix := range []string{"flag provided but not defined: -", "flag needs an argument: -", " for flag -"} {
+		if i := strings.Index(msg, prefix); i >= 0 {
+			rest := msg[i+len(prefix):]
+			name, _, _ := strings.Cut(rest, ":")
+			if len(name) > 1 {
+				msg = msg[:i+len(prefix)] + "-" + rest
+			}
+		}
+	}
+	return errors.New(msg)
+}
+
+// parseInterspersed parses flags anywhere in args and returns the
+// positional arguments in order. Everything after "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		// Parse stops at the first positional argument, or consumes "--"
+		parsed := args[:len(args)-fs.NArg()]
+		args = fs.Args()
+		if len(parsed) > 0 && parsed[len(parsed)-1] == "--" {
+			return append(rest, args...), nil
+		}
+		if len(args) == 0 {
+			return rest, nil
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testApp is an app with a global flag, a command with flags and a
+// command with subcommands, recording what ran
+type testApp struct {
+	*App
+	out     bytes.Buffer
+	errOut  bytes.Buffer
+	verbose bool
+	work    int
+	tags    string
+	ran     []string
+	args    []string
+	before  int
+	path    string // Given to Before
+}
+
+func newTestApp() *testApp {
+	ta := &testApp{}
+	ta.App = &App{Name: "qomo", Version: "1.0", Stdout: &ta.out, Stderr: &ta.errOut}
+	ta.Flags().BoolVar(&ta.verbose, "verbose", false, "Say more")
+	ta.Before = func(path string) error {
+		ta.before++
+		ta.path = path
+		return nil
+	}
+
+	record := func(name string) func([]string) error {
+		return func(args []string) error {
+			ta.ran = append(ta.ran, name)
+			ta.args = args
+			return nil
+		}
+	}
+
+	add := &Command{Name: "add", Aliases: []string{"new"}, Args: "<title>", Summary: "Add a task", Run: record("add")}
+	add.Flags().IntVar(&ta.work, "work", 0, "Work `score`")
+	add.Flags().StringVar(&ta.tags, "tags", "", "Comma-separated tags")
+
+	ta.Commands = []*Command{
+		add,
+		{
+			Name:    "backup",
+			Summary: "Create a backup",
+			Run:     NoArgs(func() error { return record("backup")(nil) }),
+			Commands: []*Command{
+				{Name: "list", Summary: "List backups", Run: record("backup list")},
+			},
+		},
+		{
+			Name:    "fail",
+			Summary: "Always fails",
+			Run: func(args []string) error {
+				if len(args) == 0 {
+					return Usagef("missing reason")
+				}
+				return errors.New("it broke")
+			},
+		},
+	}
+	return ta
+}
+
+func TestApp_Run(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantRan     []string
+		wantArgs    []string
+		wantWork    int
+		wantTags    string
+		wantVerbose bool
+	}{
+		{
+			name:     "flags anywhere after the command",
+			args:     []string{"add", "Fix", "--work", "4", "bug", "--tags=api"},
+			wantRan:  []string{"add"},
+			wantArgs: []string{"Fix", "bug"},
+			wantWork: 4,
+			wantTags: "api",
+		},
+		{
+			name:        "global flags before and after the command",
+			args:        []string{"--verbose", "new", "Fix"},
+			wantRan:     []string{"add"},
+			wantArgs:    []string{"Fix"},
+			wantVerbose: true,
+		},
+		{
+			name:        "global flag among command flags",
+			args:        []string{"add", "Fix", "--verbose"},
+			wantRan:     []string{"add"},
+			wantArgs:    []string{"Fix"},
+			wantVerbose: true,
+		},
+		{
+			name:     "everything after -- is positional",
+			args:     []string{"add", "--work", "2", "--", "--not-a-flag", "-x"},
+			wantRan:  []string{"add"},
+			wantArgs: []string{"--not-a-flag", "-x"},
+			wantWork: 2,
+		},
+		{
+			name:     "subcommand",
+			args:     []string{"backup", "list", "old"},
+			wantRan:  []string{"backup list"},
+			wantArgs: []string{"old"},
+		},
+		{
+			name:    "parent of subcommands",
+			args:    []string{"BACKUP"},
+			wantRan: []string{"backup"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestApp()
+			if err := ta.Run(tt.args); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !reflect.DeepEqual(ta.ran, tt.wantRan) || !reflect.DeepEqual(ta.args, tt.wantArgs) {
+				t.Errorf("Run() ran %v with %q, want %v with %q", ta.ran, ta.args, tt.wantRan, tt.wantArgs)
+			}
+			if ta.work != tt.wantWork || ta.tags != tt.wantTags || ta.verbose != tt.wantVerbose {
+				t.Errorf("flags = work %d, tags %q, verbose %v, want %d, %q, %v",
+					ta.work, ta.tags, ta.verbose, tt.wantWork, tt.wantTags, tt.wantVerbose)
+			}
+			if ta.before != 1 || ta.path != tt.wantRan[0] {
+				t.Errorf("Before ran %d times with %q, want once with %q", ta.before, ta.path, tt.wantRan[0])
+			}
+		})
+	}
+}
+
+func TestApp_Execute(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{name: "success", args: []string{"add", "Fix"}, wantCode: 0},
+		{name: "unknown command", args: []string{"frob"}, wantCode: 2, wantErr: "❌ unknown command \"frob\"\nRun 'qomo help' for usage.\n"},
+		{name: "unknown flag", args: []string{"add", "--bogus"}, wantCode: 2, wantErr: "❌ flag provided but not defined: --bogus\nRun 'qomo help add' for usage.\n"},
+		{name: "invalid flag value", args: []string{"add", "--work", "x"}, wantCode: 2, wantErr: "--work: parse error"},
+		{name: "unexpected argument", args: []string{"backup", "now"}, wantCode: 2, wantErr: "Run 'qomo help backup' for usage."},
+		{name: "usage error from the command", args: []string{"fail"}, wantCode: 2, wantErr: "❌ missing reason\nRun 'qomo help fail' for usage.\n"},
+		{name: "other error", args: []string{"fail", "now"}, wantCode: 1, wantErr: "❌ it broke\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestApp()
+			if got := ta.Execute(tt.args); got != tt.wantCode {
+				t.Errorf("Execute() = %d, want %d", got, tt.wantCode)
+			}
+			if tt.wantErr == "" && ta.errOut.Len() > 0 || !strings.Contains(ta.errOut.String(), tt.wantErr) {
+				t.Errorf("Execute() reported %q, want %q", ta.errOut.String(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApp_RunHelpAndVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "help", args: []string{"help"}, want: "COMMANDS:"},
+		{name: "--help", args: []string{"--help"}, want: "GLOBAL FLAGS:"},
+		{name: "help command", args: []string{"help", "add"}, want: "qomo add <title> [flags]"},
+		{name: "help subcommand", args: []string{"help", "backup", "list"}, want: "qomo backup list"},
+		{name: "command -h", args: []string{"add", "Fix", "-h"}, want: "--work score"},
+		{name: "--version", args: []string{"--version"}, want: "qomo 1.0\n"},
+		{name: "-v after the command", args: []string{"add", "-v"}, want: "qomo 1.0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestApp()
+			if err := ta.Run(tt.args); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !strings.Contains(ta.out.String(), tt.want) {
+				t.Errorf("Run() wrote\n%s\nwant it to contain %q", ta.out.String(), tt.want)
+			}
+			if len(ta.ran) > 0 || ta.before > 0 {
+				t.Errorf("Run() ran %v and Before %d times, want neither", ta.ran, ta.before)
+			}
+		})
+	}
+}
+
+func TestApp_RunDefault(t *testing.T) {
+	ta := newTestApp()
+	ran := false
+	ta.Default = func() error {
+		ran = true
+		return nil
+	}
+	if err := ta.Run([]string{"--verbose"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !ran || ta.before != 1 || ta.path != "" || !ta.verbose {
+		t.Errorf("Run() without a command: default ran %v, Before %d times with %q, verbose %v, want true, 1, \"\", true", ran, ta.before, ta.path, ta.verbose)
+	}
+}
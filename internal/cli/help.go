@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// PrintHelp writes the app help: the header, usage, every command with its
+// subcommands, the global flags and the footer
+func (a *App) PrintHelp() {
+	w := a.stdout()
+	if a.Header != "" {
+		fmt.Fprintf(w, "%s\n\n", strings.TrimRight(a.Header, "\n"))
+	}
+
+	fmt.Fprintf(w, "USAGE:\n    %s [global flags] <command> [arguments]\n\n", a.Name)
+
+	fmt.Fprintln(w, "COMMANDS:")
+	var list func(prefix string, commands []*Command)
+	list = func(prefix string, commands []*Command) {
+		for _, cmd := range commands {
+			path := prefix + cmd.Name
+			if cmd.Run != nil {
+				fmt.Fprintf(w, "    %s\n", strings.TrimSpace(path+" "+cmd.usageArgs()))
+				fmt.Fprintf(w, "        %s\n\n", cmd.Summary)
+			}
+			list(path+" ", cmd.Commands)
+		}
+	}
+	list("", a.Commands)
+	fmt.Fprintf(w, "    help [command]\n        Show help for the app or a command\n\n")
+
+	fmt.Fprintln(w, "GLOBAL FLAGS:")
+	writeFlags(w, a.Flags())
+	fmt.Fprintf(w, "\nRun '%s help <command>' for the flags and examples of a command.\n", a.Name)
+
+	if a.Footer != "" {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimRight(a.Footer, "\n"))
+	}
+}
+
+// PrintCommandHelp writes the help of the command at path: its usage,
+// description, flags, subcommands and examples
+func (a *App) PrintCommandHelp(path string, cmd *Command) {
+	w := a.stdout()
+
+	fmt.Fprintf(w, "USAGE:\n    %s\n", strings.TrimSpace(a.Name+" "+path+" "+cmd.usageArgs()))
+	for _, sub := range cmd.Commands {
+		fmt.Fprintf(w, "    %s\n", strings.TrimSpace(a.Name+" "+path+" "+sub.Name+" "+sub.usageArgs()))
+	}
+
+	fmt.Fprintf(w, "\n%s\n", cmd.Summary)
+	if cmd.Help != "" {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(cmd.Help))
+	}
+
+	if hasFlags(cmd.Flags()) {
+		fmt.Fprintln(w, "\nFLAGS:")
+		writeFlags(w, cmd.Flags())
+	}
+
+	if len(cmd.Commands) > 0 {
+		fmt.Fprintln(w, "\nCOMMANDS:")
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, sub := range cmd.Commands {
+			fmt.Fprintf(tw, "    %s\t%s\n", sub.Name, sub.Summary)
+		}
+		tw.Flush()
+	}
+
+	if len(cmd.Examples) > 0 {
+		fmt.Fprintln(w, "\nEXAMPLES:")
+		for _, example := range cmd.Examples {
+			fmt.Fprintf(w, "    %s %s\n", a.Name, example)
+		}
+	}
+
+	if len(cmd.Aliases) > 0 {
+		fmt.Fprintf(w, "\nALIASES:\n    %s\n", strings.Join(cmd.Aliases, ", "))
+	}
+
+	fmt.Fprintf(w, "\nRun '%s help' for the global flags.\n", a.Name)
+}
+
+// usageArgs returns the arguments part of the usage line
+func (c *Command) usageArgs() string {
+	if hasFlags(c.Flags()) {
+		return strings.TrimSpace(c.Args + " [flags]")
+	}
+	return c.Args
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+// writeFlags writes one aligned line per flag with its value name, usage
+// and non-zero default. Flags sharing a value, such as a short form, are
+// listed together.
+func writeFlags(w io.Writer, fs *flag.FlagSet) {
+	type entry struct {
+		names []string
+		value string
+		usage string
+		def   string
+	}
+	var entries []*entry
+	byValue := make(map[uintptr]*entry)
+
+	fs.VisitAll(func(f *flag.Flag) {
+		name := "--" + f.Name
+		if len(f.Name) == 1 {
+			name = "-" + f.Name
+		}
+		key := valueKey(f.Value)
+		if e, ok := byValue[key]; ok && key != 0 {
+			e.names = append(e.names, name)
+			return
+		}
+
+		valueName, usage := flag.UnquoteUsage(f)
+		e := &entry{names: []string{name}, value: valueName, usage: usage}
+		if !isZeroDefault(f.DefValue) {
+			e.def = f.DefValue
+		}
+		entries = append(entries, e)
+		byValue[key] = e
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, e := range entries {
+		left := strings.Join(e.names, ", ")
+		if e.value != "" {
+			left += " " + e.value
+		}
+		usage := e.usage
+		if e.def != "" {
+			usage += fmt.Sprintf(" (default %s)", e.def)
+		}
+		fmt.Fprintf(tw, "    %s\t%s\n", left, usage)
+	}
+	tw.Flush()
+}
+
+// valueKey identifies the variable behind a flag value, or returns 0 for
+// values such as flag.Func that are not pointers
+func valueKey(value flag.Value) uintptr {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Pointer {
+		return 0
+	}
+	return v.Pointer()
+}
+
+// isZeroDefault reports whether a flag default is not worth showing
+func isZeroDefault(value string) bool {
+	switch value {
+	case "", "0", "0s", "false", "[]":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestWriteFlags(t *testing.T) {
+	var (
+		dryRun bool
+		focus  = 25 * time.Minute
+		rounds int
+	)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.BoolVar(&dryRun, "dry-run", false, "Only list what would change")
+	fs.BoolVar(&dryRun, "n", false, "Only list what would change")
+	fs.DurationVar(&focus, "focus", focus, "Length of a focus phase")
+	fs.IntVar(&rounds, "rounds", 0, "Run `N` focus rounds")
+	fs.Func("notify", "Comma-separated `list` of notifiers", func(string) error { return nil })
+
+	var out bytes.Buffer
+	writeFlags(&out, fs)
+
+	want := "" +
+		"    --dry-run, -n     Only list what would change\n" +
+		"    --focus duration  Length of a focus phase (default 25m0s)\n" +
+		"    --notify list     Comma-separated list of notifiers\n" +
+		"    --rounds N        Run N focus rounds\n"
+	if got := out.String(); got != want {
+		t.Errorf("writeFlags() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Storage backend names
+const (
+	BackendFile   = "file"
+	BackendSQLite = "sqlite"
+)
+
+// Config holds user preferences loaded from config.json
+type Config struct {
+	Storage  StorageConfig  `json:"storage" yaml:"storage"`
+	Backup   BackupConfig   `json:"backup" yaml:"backup"`
+	UI       UIConfig       `json:"ui" yaml:"ui"`
+	Pomodoro PomodoroConfig `json:"pomodoro" yaml:"pomodoro"`
+}
+
+// StorageConfig selects and tunes the persistence layer
+type StorageConfig struct {
+	Backend     string   `json:"backend" yaml:"backend"`           // "file" (default) or "sqlite"
+	LockTimeout Duration `json:"lock_timeout" yaml:"lock_timeout"` // Wait for other processes writing the data directory
+}
+
+// BackupConfig controls automatic backups and how many are kept
+type BackupConfig struct {
+	Auto        bool `json:"auto" yaml:"auto"`                 // Back up before the first write of each day; off by default
+	KeepDaily   int  `json:"keep_daily" yaml:"keep_daily"`     // Newest backup of each of the last N days
+	KeepWeekly  int  `json:"keep_weekly" yaml:"keep_weekly"`   // Newest backup of each of the last N weeks
+	KeepMonthly int  `json:"keep_monthly" yaml:"keep_monthly"` // Newest backup of each of the last N months
+}
+
+// UIConfig controls the interactive interface
+type UIConfig struct {
+	TUIByDefault bool `json:"tui_by_default" yaml:"tui_by_default"` // Open the TUI when run without a command
+}
+
+// PomodoroConfig sets the length of the phases of a pomodoro cycle
+type PomodoroConfig struct {
+	Focus          Duration `json:"focus" yaml:"focus"`
+	ShortBreak     Duration `json:"short_break" yaml:"short_break"`
+	LongBreak      Duration `json:"long_break" yaml:"long_break"`
+	LongBreakEvery int      `json:"long_break_every" yaml:"long_break_every"` // Focus phases before a long break
+	AutoStart      bool     `json:"auto_start" yaml:"auto_start"`             // Start the next phase without waiting for Enter
+	Notify         []string `json:"notify" yaml:"notify"`                     // Any of "sound", "bell", "desktop" or "none"
+	StartSound     string   `json:"start_sound" yaml:"start_sound"`           // WAV, OGG or MP3 file, relative to the config directory
+	StopSound      string   `json:"stop_sound" yaml:"stop_sound"`
+}
+
+// Duration is a time.Duration written as a string such as "5s" or "25m"
+type Duration struct {
+	time.Duration
+}
+
+// MarshalJSON encodes the duration in time.Duration string form
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts a duration string or a number of nanoseconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case float64:
+		d.Duration = time.Duration(v)
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", v, err)
+		}
+		d.Duration = parsed
+	default:
+		return fmt.Errorf("invalid duration %s", string(data))
+	}
+	return nil
+}
+
+// Default returns the configuration used when no config file exists
+func Default() *Config {
+	return &Config{
+		Storage: StorageConfig{
+			Backend:     BackendFile,
+			LockTimeout: Duration{5 * time.Second},
+		},
+		Backup: BackupConfig{
+			KeepDaily:   7,
+			KeepWeekly:  4,
+			KeepMonthly: 6,
+		},
+		Pomodoro: PomodoroConfig{
+			Focus:          Duration{25 * time.Minute},
+			ShortBreak:     Duration{5 * time.Minute},
+			LongBreak:      Duration{15 * time.Minute},
+			LongBreakEvery: 4,
+			AutoStart:      true,
+			Notify:         []string{"sound"},
+		},
+	}
+}
+
+// Validate checks that all configured values are supported
+func (c *Config) Validate() error {
+	switch c.Storage.Backend {
+	case BackendFile, BackendSQLite:
+	default:
+		return fmt.Errorf("unknown storage backend %q (want %q or %q)", c.Storage.Backend, BackendFile, BackendSQLite)
+	}
+	if c.Storage.LockTimeout.Duration < 0 {
+		return fmt.Errorf("storage lock_timeout must not be negative")
+	}
+	if c.Backup.KeepDaily < 0 || c.Backup.KeepWeekly < 0 || c.Backup.KeepMonthly < 0 {
+		return fmt.Errorf("backup keep_daily, keep_weekly and keep_monthly must not be negative")
+	}
+	if c.Pomodoro.Focus.Duration <= 0 || c.Pomodoro.ShortBreak.Duration <= 0 || c.Pomodoro.LongBreak.Duration <= 0 {
+		return fmt.Errorf("pomodoro focus, short_break and long_break must be longer than zero")
+	}
+	if c.Pomodoro.LongBreakEvery < 1 {
+		return fmt.Errorf("pomodoro long_break_every must be at least 1")
+	}
+	return nil
+}
+
+// Dir returns the configuration directory for the application
+func Dir(appName string) (string, error) {
+	// Try XDG_CONFIG_HOME first
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); xdgConfigHome != "" {
+		return filepath.Join(xdgConfigHome, appName), nil
+	}
+
+	// Fall back to ~/.config
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".config", appName), nil
+}
+
+// Load reads config.json from dir, filling unset values with defaults.
+// A missing file is not an error.
+func Load(dir string) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if cfg.Storage.Backend == "" {
+		cfg.Storage.Backend = BackendFile
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDate parses a day relative to now: "today", "tomorrow",
+// "yesterday", a weekday name such as "friday" or "fri" for the next one
+// after today, "+3d" or "+2w" for days or weeks from today, or YYYY-MM-DD.
+// The result is midnight of that day in now's location.
+func ParseDate(value string, now time.Time) (time.Time, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	switch value {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := strings.ToLower(weekday.String())
+		if value == name || value == name[:3] {
+			days := (int(weekday)-int(today.Weekday())+6)%7 + 1
+			return today.AddDate(0, 0, days), nil
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(value, "+"); ok && len(rest) > 1 {
+		n, err := strconv.Atoi(rest[:len(rest)-1])
+		if err == nil && n >= 0 {
+			switch rest[len(rest)-1] {
+			case 'd':
+				return today.AddDate(0, 0, n), nil
+			case 'w':
+				return today.AddDate(0, 0, 7*n), nil
+			}
+		}
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, want today, tomorrow, a weekday, +3d, +2w or YYYY-MM-DD", value)
+}
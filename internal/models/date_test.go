@@ -0,0 +1,71 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	// A Wednesday afternoon
+	now := time.Date(2024, 3, 6, 15, 30, 0, 0, time.Local)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "today", want: day(3, 6)},
+		{value: "Tomorrow", want: day(3, 7)},
+		{value: "yesterday", want: day(3, 5)},
+		{value: "friday", want: day(3, 8)},
+		{value: "mon", want: day(3, 11)},
+		{value: "wednesday", want: day(3, 13)},
+		{value: "+3d", want: day(3, 9)},
+		{value: "+2w", want: day(3, 20)},
+		{value: "2024-04-01", want: day(4, 1)},
+		{value: "someday", wantErr: true},
+		{value: "+d", wantErr: true},
+		{value: "+3m", wantErr: true},
+		{value: "2024-13-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDate(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("ParseDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTask_IsOverdue(t *testing.T) {
+	due := time.Date(2024, 3, 6, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name   string
+		status TaskStatus
+		due    *time.Time
+		now    time.Time
+		want   bool
+	}{
+		{name: "no due date", status: TaskStatusPending, now: due.AddDate(0, 1, 0), want: false},
+		{name: "on the due day", status: TaskStatusPending, due: &due, now: due.Add(23 * time.Hour), want: false},
+		{name: "after the due day", status: TaskStatusPaused, due: &due, now: due.AddDate(0, 0, 1).Add(time.Minute), want: true},
+		{name: "completed", status: TaskStatusCompleted, due: &due, now: due.AddDate(0, 0, 2), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{Status: tt.status, DueDate: tt.due}
+			if got := task.IsOverdue(tt.now); got != tt.want {
+				t.Errorf("IsOverdue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// EventAction is what a task event did to the task
+type EventAction string
+
+const (
+	EventCreate EventAction = "create"
+	EventUpdate EventAction = "update"
+	EventDelete EventAction = "delete"
+)
+
+// TaskEvent records one change to a task in the history log. Events are
+// only ever appended, so they outlive the task they describe.
+type TaskEvent struct {
+	At      time.Time     `json:"at" yaml:"at"`
+	Action  EventAction   `json:"action" yaml:"action"`
+	TaskID  string        `json:"task_id" yaml:"task_id"`
+	ShortID int           `json:"short_id,omitempty" yaml:"short_id,omitempty"`
+	Title   string        `json:"title" yaml:"title"`                       // After the change, or when deleted
+	Source  string        `json:"source,omitempty" yaml:"source,omitempty"` // What made the change, e.g. "cli edit" or "tui"
+	Changes []FieldChange `json:"changes,omitempty" yaml:"changes,omitempty"`
+}
+
+// FieldChange is the old and new value of one task field, as they appear
+// in the task's JSON. Old is nil for fields a change sets and New for
+// fields it clears.
+type FieldChange struct {
+	Field string `json:"field" yaml:"field"` // JSON name, with nested fields dotted as in score.work
+	Old   any    `json:"old,omitempty" yaml:"old,omitempty"`
+	New   any    `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// NewTaskEvent returns the event for changing old into new at the given
+// time. Old is nil for a create and new for a delete. It returns nil for
+// an update that changes nothing.
+func NewTaskEvent(action EventAction, old, new *Task, at time.Time, source string) (*TaskEvent, error) {
+	changes, err := DiffTasks(old, new)
+	if err != nil {
+		return nil, err
+	}
+	if action == EventUpdate && len(changes) == 0 {
+		return nil, nil
+	}
+
+	task := new
+	if task == nil {
+		task = old
+	}
+	return &TaskEvent{
+		At:      at,
+		Action:  action,
+		TaskID:  task.ID,
+		ShortID: task.ShortID,
+		Title:   task.Title,
+		Source:  source,
+		Changes: changes,
+	}, nil
+}
+
+// DiffTasks returns the fields that differ between old and new, sorted by
+// name. Either may be nil, making every field that is set a change. Lists
+// such as tags and time entries are compared and recorded whole, and
+// updated_at is left out as every save changes it.
+func DiffTasks(old, new *Task) ([]FieldChange, error) {
+	oldFields, err := taskFields(old)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := taskFields(new)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FieldChange
+	for field, value := range oldFields {
+		if other, ok := newFields[field]; !ok || !reflect.DeepEqual(value, other) {
+			changes = append(changes, FieldChange{Field: field, Old: value, New: other})
+		}
+	}
+	for field, value := range newFields {
+		if _, ok := oldFields[field]; !ok {
+			changes = append(changes, FieldChange{Field: field, New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// taskFields flattens the JSON of task into dotted field names
+func taskFields(task *Task) (map[string]any, error) {
+	fields := make(map[string]any)
+	if task == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var flatten func(prefix string, doc map[string]any)
+	flatten = func(prefix string, doc map[string]any) {
+		for name, value := range doc {
+			if nested, ok := value.(map[string]any); ok {
+				flatten(prefix+name+".", nested)
+				continue
+			}
+			fields[prefix+name] = value
+		}
+	}
+	flatten("", doc)
+	delete(fields, "updated_at")
+	return fields, nil
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffTasks(t *testing.T) {
+	created := time.Date(2024, 3, 6, 9, 0, 0, 0, time.UTC)
+	base := func() *Task {
+		return &Task{
+			ID: "task_1", ShortID: 1, Title: "Fix leak", Description: "In the API",
+			Score: Score{Work: 3}, Tags: []string{"api"}, CreatedAt: created, UpdatedAt: created,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Task)
+		want   []FieldChange
+	}{
+		{
+			name:   "nothing",
+			change: func(task *Task) { task.UpdatedAt = created.Add(time.Hour) },
+			want:   nil,
+		},
+		{
+			name: "score and status",
+			change: func(task *Task) {
+				task.Score.Work = 5
+				task.Status = TaskStatusCompleted
+			},
+			want: []FieldChange{
+				{Field: "score.work", Old: float64(3), New: float64(5)},
+				{Field: "status", Old: float64(TaskStatusPending), New: float64(TaskStatusCompleted)},
+			},
+		},
+		{
+			name:   "tags compared whole",
+			change: func(task *Task) { task.Tags = append(task.Tags, "bug") },
+			want:   []FieldChange{{Field: "tags", Old: []any{"api"}, New: []any{"api", "bug"}}},
+		},
+		{
+			name: "cleared and set",
+			change: func(task *Task) {
+				task.Description = ""
+				task.CanonicalHour = "Prime"
+			},
+			want: []FieldChange{
+				{Field: "canonical_hour", New: "Prime"},
+				{Field: "description", Old: "In the API"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := base()
+			tt.change(updated)
+			got, err := DiffTasks(base(), updated)
+			if err != nil {
+				t.Fatalf("DiffTasks() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffTasks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTaskEvent(t *testing.T) {
+	at := time.Date(2024, 3, 6, 10, 0, 0, 0, time.UTC)
+	task := &Task{ID: "task_1", ShortID: 4, Title: "Walk", Score: Score{Play: 4}, CreatedAt: at}
+
+	event, err := NewTaskEvent(EventCreate, nil, task, at, "cli add")
+	if err != nil {
+		t.Fatalf("NewTaskEvent() error = %v", err)
+	}
+	if event.TaskID != "task_1" || event.ShortID != 4 || event.Title != "Walk" || event.Source != "cli add" {
+		t.Errorf("NewTaskEvent() = %+v, want task_1 #4 Walk from cli add", event)
+	}
+	for _, change := range event.Changes {
+		if change.Old != nil {
+			t.Errorf("create change %s has old value %v, want none", change.Field, change.Old)
+		}
+	}
+
+	event, err = NewTaskEvent(EventDelete, task, nil, at, "")
+	if err != nil {
+		t.Fatalf("NewTaskEvent() error = %v", err)
+	}
+	if event.Title != "Walk" || len(event.Changes) == 0 {
+		t.Errorf("delete event = %+v, want the title and the final fields", event)
+	}
+
+	if event, err = NewTaskEvent(EventUpdate, task, task, at, ""); err != nil || event != nil {
+		t.Errorf("NewTaskEvent() for an unchanged task = %v, %v, want nil, nil", event, err)
+	}
+}
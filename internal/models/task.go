@@ -1,6 +1,10 @@
 package models
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -60,19 +64,25 @@ func (ts TaskStatus) String() string {
 // Task represents a single activity or work item
 type Task struct {
 	ID          string     `json:"id" yaml:"id"`
+	ShortID     int        `json:"short_id,omitempty" yaml:"short_id,omitempty"` // Assigned by storage, never reused
 	Title       string     `json:"title" yaml:"title"`
 	Description string     `json:"description,omitempty" yaml:"description,omitempty"`
 	Score       Score      `json:"score" yaml:"score"`
 	Status      TaskStatus `json:"status" yaml:"status"`
 
-	// Timing information
-	EstimatedDuration time.Duration `json:"estimated_duration" yaml:"estimated_duration"`
-	ActualDuration    time.Duration `json:"actual_duration,omitempty" yaml:"actual_duration,omitempty"`
-	StartTime         *time.Time    `json:"start_time,omitempty" yaml:"start_time,omitempty"`
-	EndTime           *time.Time    `json:"end_time,omitempty" yaml:"end_time,omitempty"`
+	// Timing information. ActualDuration is the sum of the closed time
+	// entries and StartTime the start of the running one.
+	EstimatedDuration time.Duration  `json:"estimated_duration" yaml:"estimated_duration"`
+	ActualDuration    time.Duration  `json:"actual_duration,omitempty" yaml:"actual_duration,omitempty"`
+	StartTime         *time.Time     `json:"start_time,omitempty" yaml:"start_time,omitempty"`
+	EndTime           *time.Time     `json:"end_time,omitempty" yaml:"end_time,omitempty"`
+	TimeEntries       []TimeEntry    `json:"time_entries,omitempty" yaml:"time_entries,omitempty"`
+	Pomodoros         int            `json:"pomodoros,omitempty" yaml:"pomodoros,omitempty"` // Completed focus intervals
+	Interruptions     []Interruption `json:"interruptions,omitempty" yaml:"interruptions,omitempty"`
 
 	// Scheduling
 	ScheduledTime *time.Time `json:"scheduled_time,omitempty" yaml:"scheduled_time,omitempty"`
+	DueDate       *time.Time `json:"due_date,omitempty" yaml:"due_date,omitempty"` // Midnight of the day it is due
 	CanonicalHour string     `json:"canonical_hour,omitempty" yaml:"canonical_hour,omitempty"`
 
 	// Metadata
@@ -86,6 +96,41 @@ type Task struct {
 	Reflection string `json:"reflection,omitempty" yaml:"reflection,omitempty"`
 }
 
+// Ref returns the short ID as shown to users, e.g. "#12"
+func (t *Task) Ref() string {
+	return fmt.Sprintf("#%d", t.ShortID)
+}
+
+// MatchID returns the tasks that ref identifies: the task with that short
+// ID (with or without a leading #), else the task with that full ID, else
+// every task whose full ID starts with ref. More than one match means ref
+// is an ambiguous prefix.
+func MatchID(tasks []*Task, ref string) []*Task {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil
+	}
+
+	if n, err := strconv.Atoi(strings.TrimPrefix(ref, "#")); err == nil {
+		for _, task := range tasks {
+			if task.ShortID == n {
+				return []*Task{task}
+			}
+		}
+	}
+
+	var matches []*Task
+	for _, task := range tasks {
+		if task.ID == ref {
+			return []*Task{task}
+		}
+		if strings.HasPrefix(task.ID, ref) {
+			matches = append(matches, task)
+		}
+	}
+	return matches
+}
+
 // IsActive returns true if the task is currently being worked on
 func (t *Task) IsActive() bool {
 	return t.Status == TaskStatusActive
@@ -96,48 +141,288 @@ func (t *Task) IsCompleted() bool {
 	return t.Status == TaskStatusCompleted
 }
 
-// Start begins work on the task
+// Start begins work on the task, opening a new time entry
 func (t *Task) Start() {
 	now := time.Now()
-	t.StartTime = &now
+	t.adoptUntrackedTime(now)
+	if t.runningEntry() == nil {
+		t.openEntry(now)
+	}
 	t.Status = TaskStatusActive
 	t.UpdatedAt = now
 }
 
-// Complete finishes the task
+// Complete finishes the task, closing any running time entry
 func (t *Task) Complete() {
 	now := time.Now()
+	t.closeEntry(now)
+
 	t.EndTime = &now
 	t.CompletedAt = &now
 	t.Status = TaskStatusCompleted
 	t.UpdatedAt = now
-
-	if t.StartTime != nil {
-		t.ActualDuration = now.Sub(*t.StartTime)
-	}
 }
 
 // Pause temporarily stops work on the task
 func (t *Task) Pause() {
-	if t.Status == TaskStatusActive && t.StartTime != nil {
-		now := time.Now()
-		t.ActualDuration += now.Sub(*t.StartTime)
-		t.StartTime = nil
+	t.PauseAt(time.Now())
+}
+
+// PauseAt pauses the task as of an earlier time, for work known to have
+// stopped before it could be recorded
+func (t *Task) PauseAt(at time.Time) {
+	if t.Status == TaskStatusActive {
+		t.closeEntry(at)
 		t.Status = TaskStatusPaused
-		t.UpdatedAt = now
+		t.UpdatedAt = time.Now()
 	}
 }
 
-// Resume continues work on a paused task
+// Resume continues work on a paused task, opening a new time entry
 func (t *Task) Resume() {
 	if t.Status == TaskStatusPaused {
 		now := time.Now()
-		t.StartTime = &now
+		t.openEntry(now)
 		t.Status = TaskStatusActive
 		t.UpdatedAt = now
 	}
 }
 
+// Stop ends work on an active or paused task without completing it,
+// returning it to pending with the time worked so far kept
+func (t *Task) Stop() {
+	if t.Status != TaskStatusActive && t.Status != TaskStatusPaused {
+		return
+	}
+
+	now := time.Now()
+	t.closeEntry(now)
+	t.Status = TaskStatusPending
+	t.UpdatedAt = now
+}
+
+// RecordPomodoro counts a completed focus interval towards the task
+func (t *Task) RecordPomodoro() {
+	t.Pomodoros++
+	t.UpdatedAt = time.Now()
+}
+
+// RecordInterruption logs something that broke into work on the task
+func (t *Task) RecordInterruption(interruption Interruption) {
+	t.Interruptions = append(t.Interruptions, interruption)
+	t.UpdatedAt = time.Now()
+}
+
+// SessionInterruptions returns the interruptions logged during the
+// pomodoro session that started at session
+func (t *Task) SessionInterruptions(session time.Time) []Interruption {
+	var interruptions []Interruption
+	for _, interruption := range t.Interruptions {
+		if interruption.Session.Equal(session) {
+			interruptions = append(interruptions, interruption)
+		}
+	}
+	return interruptions
+}
+
+// LogTime records a stretch of work that was not tracked live. The entry
+// must end after it starts and must not overlap existing entries.
+func (t *Task) LogTime(start, end time.Time, note string) error {
+	if !end.After(start) {
+		return fmt.Errorf("time entry must end after it starts")
+	}
+
+	now := time.Now()
+	t.adoptUntrackedTime(now)
+	for _, entry := range t.TimeEntries {
+		if entry.Overlap(start, end, now) > 0 {
+			return fmt.Errorf("time entry overlaps %s", entry.String())
+		}
+	}
+
+	t.TimeEntries = append(t.TimeEntries, TimeEntry{Start: start, End: &end, Note: note})
+	sort.SliceStable(t.TimeEntries, func(i, j int) bool {
+		return t.TimeEntries[i].Start.Before(t.TimeEntries[j].Start)
+	})
+	t.ActualDuration = t.trackedDuration(now, false)
+	t.UpdatedAt = now
+	return nil
+}
+
+// Elapsed returns the total time worked on the task as of now, including
+// the currently running entry of an active task
+func (t *Task) Elapsed(now time.Time) time.Duration {
+	if len(t.TimeEntries) == 0 {
+		// Tasks tracked before time entries existed
+		elapsed := t.ActualDuration
+		if t.Status == TaskStatusActive && t.StartTime != nil {
+			elapsed += now.Sub(*t.StartTime)
+		}
+		return elapsed
+	}
+	return t.trackedDuration(now, true)
+}
+
+// AssignCanonicalHours labels every time entry that has no canonical hour
+// with the hour of the schedule it started in
+func (t *Task) AssignCanonicalHours(schedule *Schedule) {
+	for i := range t.TimeEntries {
+		if t.TimeEntries[i].CanonicalHour != "" {
+			continue
+		}
+		if hour := schedule.GetCurrentHour(t.TimeEntries[i].Start); hour != nil {
+			t.TimeEntries[i].CanonicalHour = hour.Name
+		}
+	}
+}
+
+// IsOverdue reports whether the task is still open after its due day
+func (t *Task) IsOverdue(now time.Time) bool {
+	if t.DueDate == nil || t.IsCompleted() || t.Status == TaskStatusCancelled {
+		return false
+	}
+	return now.After(t.DueDate.AddDate(0, 0, 1))
+}
+
+// runningEntry returns the open time entry, if any
+func (t *Task) runningEntry() *TimeEntry {
+	for i := range t.TimeEntries {
+		if t.TimeEntries[i].IsRunning() {
+			return &t.TimeEntries[i]
+		}
+	}
+	return nil
+}
+
+// openEntry starts a new running time entry at now
+func (t *Task) openEntry(now time.Time) {
+	t.adoptUntrackedTime(now)
+	t.TimeEntries = append(t.TimeEntries, TimeEntry{Start: now})
+	t.StartTime = &now
+}
+
+// closeEntry ends the running time entry at now and updates ActualDuration
+func (t *Task) closeEntry(now time.Time) {
+	t.adoptUntrackedTime(now)
+	if entry := t.runningEntry(); entry != nil {
+		entry.End = &now
+	}
+	t.StartTime = nil
+	t.ActualDuration = t.trackedDuration(now, false)
+}
+
+// adoptUntrackedTime converts timing recorded before time entries existed
+// into entries, so that ActualDuration can be derived from entries alone:
+// a running StartTime becomes an open entry and any earlier ActualDuration
+// becomes a closed entry ending where that work is known to have stopped.
+func (t *Task) adoptUntrackedTime(now time.Time) {
+	if len(t.TimeEntries) > 0 {
+		return
+	}
+
+	if t.ActualDuration > 0 {
+		end := t.UpdatedAt
+		if t.EndTime != nil {
+			end = *t.EndTime
+		} else if t.StartTime != nil {
+			end = *t.StartTime
+		}
+		if end.IsZero() {
+			end = now
+		}
+		start := end.Add(-t.ActualDuration)
+		t.TimeEntries = append(t.TimeEntries, TimeEntry{Start: start, End: &end})
+	}
+
+	if t.Status == TaskStatusActive && t.StartTime != nil {
+		t.TimeEntries = append(t.TimeEntries, TimeEntry{Start: *t.StartTime})
+	}
+}
+
+// trackedDuration sums the time entries, counting a running entry up to
+// now only when includeRunning is set
+func (t *Task) trackedDuration(now time.Time, includeRunning bool) time.Duration {
+	var total time.Duration
+	for _, entry := range t.TimeEntries {
+		if entry.IsRunning() && !includeRunning {
+			continue
+		}
+		total += entry.Duration(now)
+	}
+	return total
+}
+
+// TimeEntry is a stretch of time spent working on a task
+type TimeEntry struct {
+	Start         time.Time  `json:"start" yaml:"start"`
+	End           *time.Time `json:"end,omitempty" yaml:"end,omitempty"` // Nil while the entry is running
+	CanonicalHour string     `json:"canonical_hour,omitempty" yaml:"canonical_hour,omitempty"`
+	Note          string     `json:"note,omitempty" yaml:"note,omitempty"`
+}
+
+// IsRunning returns true if the entry has not ended yet
+func (e TimeEntry) IsRunning() bool {
+	return e.End == nil
+}
+
+// Duration returns the length of the entry, measuring a running entry up
+// to now
+func (e TimeEntry) Duration(now time.Time) time.Duration {
+	end := now
+	if e.End != nil {
+		end = *e.End
+	}
+	if end.Before(e.Start) {
+		return 0
+	}
+	return end.Sub(e.Start)
+}
+
+// Overlap returns how much of the entry falls within [from, to), measuring
+// a running entry up to now
+func (e TimeEntry) Overlap(from, to, now time.Time) time.Duration {
+	start, end := e.Start, now
+	if e.End != nil {
+		end = *e.End
+	}
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// String renders the entry as a local time range such as "14:00-15:30"
+func (e TimeEntry) String() string {
+	if e.End == nil {
+		return e.Start.Local().Format("15:04") + "-now"
+	}
+	return e.Start.Local().Format("15:04") + "-" + e.End.Local().Format("15:04")
+}
+
+// InterruptionKind tells where an interruption came from
+type InterruptionKind string
+
+const (
+	InterruptionInternal InterruptionKind = "internal" // The urge to do something else
+	InterruptionExternal InterruptionKind = "external" // Someone or something else
+)
+
+// Interruption is a break in focus logged during a pomodoro
+type Interruption struct {
+	At            time.Time        `json:"at" yaml:"at"`
+	Reason        string           `json:"reason" yaml:"reason"`
+	Kind          InterruptionKind `json:"kind" yaml:"kind"`
+	Session       time.Time        `json:"session" yaml:"session"` // Start of the pomodoro session it broke into
+	Round         int              `json:"round" yaml:"round"`     // Focus round of that session
+	CanonicalHour string           `json:"canonical_hour,omitempty" yaml:"canonical_hour,omitempty"`
+}
+
 // CanonicalHour represents a traditional canonical hour time block
 type CanonicalHour struct {
 	Name        string        `json:"name" yaml:"name"`
@@ -193,7 +478,12 @@ type DailyStats struct {
 	TimeSpent      time.Duration `json:"time_spent" yaml:"time_spent"`
 
 	// Breakdown by canonical hour
-	HourlyBreakdown map[string]Score `json:"hourly_breakdown,omitempty" yaml:"hourly_breakdown,omitempty"`
+	HourlyBreakdown map[string]Score         `json:"hourly_breakdown,omitempty" yaml:"hourly_breakdown,omitempty"`
+	TimeByHour      map[string]time.Duration `json:"time_by_hour,omitempty" yaml:"time_by_hour,omitempty"`
+
+	// Interruptions logged during pomodoros, by canonical hour
+	Interruptions       int                          `json:"interruptions,omitempty" yaml:"interruptions,omitempty"`
+	InterruptionsByHour map[string]InterruptionStats `json:"interruptions_by_hour,omitempty" yaml:"interruptions_by_hour,omitempty"`
 }
 
 // CompletionRate returns the percentage of tasks completed
@@ -204,6 +494,50 @@ func (ds *DailyStats) CompletionRate() float64 {
 	return float64(ds.CompletedTasks) / float64(ds.TotalTasks) * 100.0
 }
 
+// InterruptionStats counts the interruptions of one canonical hour
+type InterruptionStats struct {
+	Internal int            `json:"internal" yaml:"internal"`
+	External int            `json:"external" yaml:"external"`
+	Reasons  map[string]int `json:"reasons,omitempty" yaml:"reasons,omitempty"`
+}
+
+// Add counts interruption
+func (s *InterruptionStats) Add(interruption Interruption) {
+	if interruption.Kind == InterruptionInternal {
+		s.Internal++
+	} else {
+		s.External++
+	}
+	if s.Reasons == nil {
+		s.Reasons = make(map[string]int)
+	}
+	s.Reasons[interruption.Reason]++
+}
+
+// Total returns the number of interruptions of either kind
+func (s InterruptionStats) Total() int {
+	return s.Internal + s.External
+}
+
+// TopReasons returns up to n reasons, most frequent first and ties in
+// alphabetical order
+func (s InterruptionStats) TopReasons(n int) []string {
+	reasons := make([]string, 0, len(s.Reasons))
+	for reason := range s.Reasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if s.Reasons[reasons[i]] != s.Reasons[reasons[j]] {
+			return s.Reasons[reasons[i]] > s.Reasons[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	if len(reasons) > n {
+		reasons = reasons[:n]
+	}
+	return reasons
+}
+
 // WeeklyStats represents aggregated statistics for a week
 type WeeklyStats struct {
 	StartDate      time.Time     `json:"start_date" yaml:"start_date"`
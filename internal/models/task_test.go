@@ -1,6 +1,7 @@
 package models
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -158,6 +159,41 @@ func TestTaskStatus_String(t *testing.T) {
 	}
 }
 
+func TestMatchID(t *testing.T) {
+	tasks := []*Task{
+		{ID: "task_1729000000001", ShortID: 1},
+		{ID: "task_1729000000002", ShortID: 2},
+		{ID: "task_1729000099999", ShortID: 12},
+	}
+
+	tests := []struct {
+		name string
+		ref  string
+		want []int // Short IDs of the matches
+	}{
+		{name: "short ID", ref: "12", want: []int{12}},
+		{name: "short ID with hash", ref: "#2", want: []int{2}},
+		{name: "unknown short ID", ref: "7", want: nil},
+		{name: "full ID", ref: "task_1729000000002", want: []int{2}},
+		{name: "unique prefix", ref: "task_17290000999", want: []int{12}},
+		{name: "ambiguous prefix", ref: "task_17290000000", want: []int{1, 2}},
+		{name: "no match", ref: "bug", want: nil},
+		{name: "empty", ref: " ", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, task := range MatchID(tasks, tt.ref) {
+				got = append(got, task.ShortID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MatchID(%q) = %v, want %v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestTask_IsActive(t *testing.T) {
 	task := &Task{Status: TaskStatusActive}
 	if !task.IsActive() {
@@ -273,6 +309,270 @@ func TestTask_Resume(t *testing.T) {
 	}
 }
 
+func TestTask_Stop(t *testing.T) {
+	startTime := time.Now().Add(-time.Minute)
+	task := &Task{
+		Status:         TaskStatusActive,
+		ActualDuration: time.Hour,
+		StartTime:      &startTime,
+	}
+
+	task.Stop()
+
+	if task.Status != TaskStatusPending {
+		t.Errorf("Task.Stop() status = %v, want %v", task.Status, TaskStatusPending)
+	}
+
+	if task.StartTime != nil {
+		t.Errorf("Task.Stop() StartTime = %v, want nil", task.StartTime)
+	}
+
+	if task.ActualDuration < time.Hour+time.Minute {
+		t.Errorf("Task.Stop() ActualDuration = %v, want >= %v", task.ActualDuration, time.Hour+time.Minute)
+	}
+
+	completed := &Task{Status: TaskStatusCompleted}
+	completed.Stop()
+	if completed.Status != TaskStatusCompleted {
+		t.Errorf("Task.Stop() on completed task status = %v, want %v", completed.Status, TaskStatusCompleted)
+	}
+}
+
+func TestTask_CompleteKeepsPausedTime(t *testing.T) {
+	startTime := time.Now().Add(-time.Minute)
+	task := &Task{
+		Status:    TaskStatusActive,
+		StartTime: &startTime,
+	}
+
+	task.Pause()
+	paused := task.ActualDuration
+	task.Resume()
+	task.Complete()
+
+	if task.ActualDuration < paused {
+		t.Errorf("Task.Complete() ActualDuration = %v, want >= %v worked before the pause", task.ActualDuration, paused)
+	}
+}
+
+func TestTask_PauseAt(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	task := &Task{
+		Status:      TaskStatusActive,
+		StartTime:   &start,
+		TimeEntries: []TimeEntry{{Start: start}},
+	}
+
+	task.PauseAt(start.Add(20 * time.Minute))
+
+	if task.Status != TaskStatusPaused {
+		t.Errorf("Task.PauseAt() status = %v, want %v", task.Status, TaskStatusPaused)
+	}
+	if task.ActualDuration != 20*time.Minute {
+		t.Errorf("Task.PauseAt() ActualDuration = %v, want 20m", task.ActualDuration)
+	}
+}
+
+func TestTask_RecordPomodoro(t *testing.T) {
+	task := &Task{Status: TaskStatusPending}
+
+	// One focus round followed by a break
+	task.Start()
+	task.RecordPomodoro()
+	task.Pause()
+
+	if task.Pomodoros != 1 {
+		t.Errorf("Task.Pomodoros = %d, want 1", task.Pomodoros)
+	}
+	if task.Status != TaskStatusPaused {
+		t.Errorf("Task status after break = %v, want %v", task.Status, TaskStatusPaused)
+	}
+	if len(task.TimeEntries) != 1 || task.TimeEntries[0].IsRunning() {
+		t.Errorf("Task.TimeEntries = %v, want one closed focus entry", task.TimeEntries)
+	}
+}
+
+func TestTask_Elapsed(t *testing.T) {
+	now := time.Now()
+	startTime := now.Add(-10 * time.Minute)
+
+	tests := []struct {
+		name string
+		task *Task
+		want time.Duration
+	}{
+		{
+			name: "active task includes running time",
+			task: &Task{Status: TaskStatusActive, ActualDuration: 5 * time.Minute, StartTime: &startTime},
+			want: 15 * time.Minute,
+		},
+		{
+			name: "paused task",
+			task: &Task{Status: TaskStatusPaused, ActualDuration: 5 * time.Minute},
+			want: 5 * time.Minute,
+		},
+		{
+			name: "completed task ignores start time",
+			task: &Task{Status: TaskStatusCompleted, ActualDuration: 5 * time.Minute, StartTime: &startTime},
+			want: 5 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.task.Elapsed(now); got != tt.want {
+				t.Errorf("Task.Elapsed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTask_TimeEntries(t *testing.T) {
+	task := &Task{Status: TaskStatusPending}
+
+	task.Start()
+	task.Pause()
+	task.Resume()
+	if task.runningEntry() == nil || task.StartTime == nil {
+		t.Fatalf("Task.Resume() left no running entry")
+	}
+	task.Complete()
+
+	if len(task.TimeEntries) != 2 {
+		t.Fatalf("TimeEntries = %d, want 2", len(task.TimeEntries))
+	}
+	var sum time.Duration
+	for _, entry := range task.TimeEntries {
+		if entry.IsRunning() {
+			t.Errorf("entry %v still running after Complete()", entry)
+		}
+		sum += entry.Duration(time.Now())
+	}
+	if task.ActualDuration != sum {
+		t.Errorf("ActualDuration = %v, want sum of entries %v", task.ActualDuration, sum)
+	}
+	if task.StartTime != nil {
+		t.Errorf("StartTime = %v, want nil after Complete()", task.StartTime)
+	}
+}
+
+func TestTask_AdoptsUntrackedTime(t *testing.T) {
+	updated := time.Now().Add(-time.Hour)
+	task := &Task{
+		Status:         TaskStatusPaused,
+		ActualDuration: 30 * time.Minute,
+		UpdatedAt:      updated,
+	}
+
+	task.Resume()
+	task.Pause()
+
+	if len(task.TimeEntries) != 2 {
+		t.Fatalf("TimeEntries = %d, want the adopted entry and the new one", len(task.TimeEntries))
+	}
+	if !task.TimeEntries[0].End.Equal(updated) || task.TimeEntries[0].Duration(time.Now()) != 30*time.Minute {
+		t.Errorf("adopted entry = %v, want 30m ending at the last update", task.TimeEntries[0])
+	}
+	if task.ActualDuration < 30*time.Minute {
+		t.Errorf("ActualDuration = %v, want >= 30m", task.ActualDuration)
+	}
+}
+
+func TestTask_LogTime(t *testing.T) {
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	tests := []struct {
+		name    string
+		start   time.Time
+		end     time.Time
+		wantErr bool
+	}{
+		{name: "before existing entry", start: at(9, 0), end: at(10, 0)},
+		{name: "touching existing entry", start: at(15, 30), end: at(16, 0)},
+		{name: "ends before it starts", start: at(12, 0), end: at(11, 0), wantErr: true},
+		{name: "overlaps existing entry", start: at(15, 0), end: at(16, 0), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end := at(15, 30)
+			task := &Task{
+				TimeEntries:    []TimeEntry{{Start: at(14, 0), End: &end}},
+				ActualDuration: 90 * time.Minute,
+			}
+
+			err := task.LogTime(tt.start, tt.end, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Task.LogTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(task.TimeEntries) != 1 {
+					t.Errorf("Task.LogTime() added an entry despite the error")
+				}
+				return
+			}
+
+			want := 90*time.Minute + tt.end.Sub(tt.start)
+			if task.ActualDuration != want {
+				t.Errorf("ActualDuration = %v, want %v", task.ActualDuration, want)
+			}
+			for i := 1; i < len(task.TimeEntries); i++ {
+				if task.TimeEntries[i].Start.Before(task.TimeEntries[i-1].Start) {
+					t.Errorf("TimeEntries not sorted by start: %v", task.TimeEntries)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeEntry_Overlap(t *testing.T) {
+	base := time.Date(2024, 3, 4, 14, 0, 0, 0, time.Local)
+	end := base.Add(time.Hour)
+	entry := TimeEntry{Start: base, End: &end}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     time.Duration
+	}{
+		{name: "contains entry", from: base.Add(-time.Hour), to: base.Add(2 * time.Hour), want: time.Hour},
+		{name: "clips start", from: base.Add(30 * time.Minute), to: base.Add(2 * time.Hour), want: 30 * time.Minute},
+		{name: "clips end", from: base.Add(-time.Hour), to: base.Add(15 * time.Minute), want: 15 * time.Minute},
+		{name: "disjoint", from: end, to: end.Add(time.Hour), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entry.Overlap(tt.from, tt.to, time.Now()); got != tt.want {
+				t.Errorf("TimeEntry.Overlap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTask_AssignCanonicalHours(t *testing.T) {
+	schedule := GetDefaultSchedule()
+	prime := time.Date(2024, 3, 4, 10, 0, 0, 0, time.Local)
+	night := time.Date(2024, 3, 4, 23, 0, 0, 0, time.Local)
+	task := &Task{TimeEntries: []TimeEntry{
+		{Start: prime},
+		{Start: prime, CanonicalHour: "Vespers"},
+		{Start: night},
+	}}
+
+	task.AssignCanonicalHours(&schedule)
+
+	want := []string{"Prime", "Vespers", ""}
+	for i, entry := range task.TimeEntries {
+		if entry.CanonicalHour != want[i] {
+			t.Errorf("TimeEntries[%d].CanonicalHour = %q, want %q", i, entry.CanonicalHour, want[i])
+		}
+	}
+}
+
 func TestCanonicalHour_IsActive(t *testing.T) {
 	hour := &CanonicalHour{
 		StartTime: "09:00",
@@ -447,6 +747,38 @@ func TestDailyStats_CompletionRate(t *testing.T) {
 	}
 }
 
+func TestInterruptionStats(t *testing.T) {
+	var stats InterruptionStats
+	for _, interruption := range []Interruption{
+		{Reason: "slack", Kind: InterruptionExternal},
+		{Reason: "email", Kind: InterruptionInternal},
+		{Reason: "slack", Kind: InterruptionExternal},
+		{Reason: "phone", Kind: InterruptionExternal},
+		{Reason: "coffee", Kind: InterruptionInternal},
+	} {
+		stats.Add(interruption)
+	}
+
+	if stats.Internal != 2 || stats.External != 3 || stats.Total() != 5 {
+		t.Errorf("InterruptionStats = %d internal, %d external, %d total, want 2, 3, 5",
+			stats.Internal, stats.External, stats.Total())
+	}
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{n: 1, want: []string{"slack"}},
+		{n: 3, want: []string{"slack", "coffee", "email"}},
+		{n: 10, want: []string{"slack", "coffee", "email", "phone"}},
+	}
+	for _, tt := range tests {
+		if got := stats.TopReasons(tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TopReasons(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
 func TestGetDefaultSchedule(t *testing.T) {
 	schedule := GetDefaultSchedule()
 
@@ -0,0 +1,96 @@
+// Package output writes command results for scripts as JSON, YAML or TSV,
+// next to the decorated text meant for people.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is how a command writes its result
+type Format string
+
+const (
+	Text Format = "text" // Decorated output for people; the default
+	JSON Format = "json"
+	YAML Format = "yaml"
+	TSV  Format = "tsv" // Header row, then one row per record
+)
+
+// String implements flag.Value
+func (f *Format) String() string {
+	if f == nil || *f == "" {
+		return string(Text)
+	}
+	return string(*f)
+}
+
+// Set implements flag.Value, accepting any of the formats in any case
+func (f *Format) Set(value string) error {
+	switch format := Format(strings.ToLower(value)); format {
+	case Text, JSON, YAML, TSV:
+		*f = format
+		return nil
+	}
+	return fmt.Errorf("want %s, %s, %s or %s", Text, JSON, YAML, TSV)
+}
+
+// IsText reports whether the result is meant for people
+func (f Format) IsText() bool {
+	return f == "" || f == Text
+}
+
+// Table is a result as rows of fields under a header, for TSV
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Write writes value as JSON or YAML using its json or yaml tags, or table
+// as TSV. Text is not handled here; commands print it themselves.
+func Write(w io.Writer, format Format, value any, table *Table) error {
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(value); err != nil {
+			return err
+		}
+		return enc.Close()
+	case TSV:
+		return writeTSV(w, table)
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
+
+// writeTSV writes the header and rows of table separated by tabs. Tabs,
+// newlines and backslashes within fields are escaped as \t, \n and \\.
+func writeTSV(w io.Writer, table *Table) error {
+	escaper := strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+	writeRow := func(fields []string) error {
+		escaped := make([]string, len(fields))
+		for i, field := range fields {
+			escaped[i] = escaper.Replace(field)
+		}
+		_, err := fmt.Fprintln(w, strings.Join(escaped, "\t"))
+		return err
+	}
+
+	if err := writeRow(table.Header); err != nil {
+		return err
+	}
+	for _, row := range table.Rows {
+		if err := writeRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+type record struct {
+	Name  string   `json:"name" yaml:"name"`
+	Tags  []string `json:"tags" yaml:"tags"`
+	Score int      `json:"score,omitempty" yaml:"score,omitempty"`
+}
+
+func TestWrite(t *testing.T) {
+	value := []record{{Name: "Fix bug", Tags: []string{"api"}, Score: 3}, {Name: "Read", Tags: []string{}}}
+	table := &Table{
+		Header: []string{"name", "note"},
+		Rows:   [][]string{{"Fix bug", "tab\there"}, {"Read", "line\nbreak and C:\\path"}},
+	}
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{
+			format: JSON,
+			want: `[
+  {
+    "name": "Fix bug",
+    "tags": [
+      "api"
+    ],
+    "score": 3
+  },
+  {
+    "name": "Read",
+    "tags": []
+  }
+]
+`,
+		},
+		{
+			format: YAML,
+			want: `- name: Fix bug
+  tags:
+    - api
+  score: 3
+- name: Read
+  tags: []
+`,
+		},
+		{
+			format: TSV,
+			want:   "name\tnote\nFix bug\ttab\\there\nRead\tline\\nbreak and C:\\\\path\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tt.format, value, table); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Write() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_Set(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Format
+		wantErr bool
+	}{
+		{value: "json", want: JSON},
+		{value: "YAML", want: YAML},
+		{value: "tsv", want: TSV},
+		{value: "text", want: Text},
+		{value: "csv", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var f Format
+			err := f.Set(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && f != tt.want {
+				t.Errorf("Set() = %v, want %v", f, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,432 @@
+// Package query filters and sorts tasks with a small expression language,
+// e.g. `status:open tag:api hour:Prime work>=4 created:this-week "memory leak"`.
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+// Query is a parsed filter expression. A task matches when it matches
+// every term.
+type Query struct {
+	terms []term
+}
+
+type term struct {
+	negate bool
+	match  func(*models.Task) bool
+}
+
+// Match reports whether task matches every term of q. The empty query
+// matches every task.
+func (q *Query) Match(task *models.Task) bool {
+	for _, t := range q.terms {
+		if t.match(task) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the tasks that match q, keeping their order
+func (q *Query) Filter(tasks []*models.Task) []*models.Task {
+	var result []*models.Task
+	for _, task := range tasks {
+		if q.Match(task) {
+			result = append(result, task)
+		}
+	}
+	return result
+}
+
+// IsEmpty reports whether q has no terms and so matches every task
+func (q *Query) IsEmpty() bool {
+	return len(q.terms) == 0
+}
+
+// ParseArgs parses a query given as command line arguments, each of which
+// is one term. An argument with spaces is a phrase, as the shell passes a
+// quoted one that way, however many arguments there are: `list "memory
+// leak"` finds the same text as `list status:open "memory leak"` does.
+// Quotes within an argument work as in Parse.
+func ParseArgs(args []string, now time.Time) (*Query, error) {
+	q := &Query{}
+	for _, arg := range args {
+		tokens, err := tokenize(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(tokens) > 1 {
+			tokens = []token{{text: arg, quoted: true}}
+		}
+		for _, tok := range tokens {
+			t, err := parseTerm(tok, now)
+			if err != nil {
+				return nil, err
+			}
+			q.terms = append(q.terms, t)
+		}
+	}
+	return q, nil
+}
+
+// Parse parses a filter expression of space-separated terms, relative to
+// now for dates:
+//
+//	status:pending        pending, active, paused, completed (or done),
+//	                      cancelled, or open for any but the last two
+//	tag:api               has the tag
+//	hour:Prime            planned for the canonical hour
+//	title:bug             title contains the text
+//	id:12                 short ID; also id>10 and the like
+//	work>=4               work, play, learn or score (the total) compared with
+//	                      :, =, !=, <, <=, > or >=
+//	estimate>30m          estimated duration compared the same way
+//	created:this-week     created, updated, completed or due within today,
+//	                      yesterday, this-week, last-week, this-month,
+//	                      last-month, this-year or a day such as tomorrow,
+//	                      fri, +3d or 2024-03-15; < and > compare with the
+//	                      start and end of that period
+//	due:none due:overdue  no due date; open after the due day
+//	"memory leak"         title or description contains the text
+//
+// Matching is case-insensitive. A leading "-" negates a term, and quotes
+// group words into one phrase.
+func Parse(expr string, now time.Time) (*Query, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{}
+	for _, tok := range tokens {
+		t, err := parseTerm(tok, now)
+		if err != nil {
+			return nil, err
+		}
+		q.terms = append(q.terms, t)
+	}
+	return q, nil
+}
+
+// token is a word of an expression with its quotes removed
+type token struct {
+	text   string
+	quoted bool // The token started with a quote, so it is a phrase
+}
+
+// tokenize splits expr on spaces outside of double or single quotes
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	var current strings.Builder
+	var quote rune
+	inToken, quoted := false, false
+
+	for _, r := range expr {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			if !inToken {
+				quoted = true
+			}
+			quote, inToken = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inToken {
+				tokens = append(tokens, token{text: current.String(), quoted: quoted})
+				current.Reset()
+				inToken, quoted = false, false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in query %q", expr)
+	}
+	if inToken {
+		tokens = append(tokens, token{text: current.String(), quoted: quoted})
+	}
+	return tokens, nil
+}
+
+// operators in the order they are tried, longest first
+var operators = []string{">=", "<=", "!=", ":", "=", "<", ">"}
+
+func parseTerm(tok token, now time.Time) (term, error) {
+	text := tok.text
+	var t term
+	if !tok.quoted && len(text) > 1 && text[0] == '-' {
+		t.negate = true
+		text = text[1:]
+	}
+
+	i := strings.IndexAny(text, ":=!<>")
+	if tok.quoted || i <= 0 {
+		if text == "" {
+			return t, fmt.Errorf("empty query term")
+		}
+		t.match = containsText(text)
+		return t, nil
+	}
+
+	name := strings.ToLower(text[:i])
+	var op string
+	for _, candidate := range operators {
+		if strings.HasPrefix(text[i:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	value := text[i+len(op):]
+	if op == "" || value == "" {
+		return t, fmt.Errorf("invalid query term %q, want field:value", tok.text)
+	}
+	if op == "=" {
+		op = ":"
+	}
+
+	var err error
+	switch name {
+	case "status":
+		t.match, err = statusMatcher(op, value)
+	case "tag", "tags":
+		t.match, err = stringMatcher(op, value, func(task *models.Task) []string { return task.Tags })
+	case "hour":
+		t.match, err = stringMatcher(op, value, func(task *models.Task) []string { return []string{task.CanonicalHour} })
+	case "title":
+		if op != ":" && op != "!=" {
+			return t, fmt.Errorf("invalid query term %q: title takes : or !=", tok.text)
+		}
+		needle := strings.ToLower(value)
+		t.match = func(task *models.Task) bool { return strings.Contains(strings.ToLower(task.Title), needle) }
+		if op == "!=" {
+			t.negate = !t.negate
+		}
+	case "id", "work", "play", "learn", "score", "score.total", "score.work", "score.play", "score.learn":
+		t.match, err = intMatcher(op, value, intField(name))
+	case "estimate":
+		t.match, err = durationMatcher(op, value)
+	case "created", "updated", "completed", "due":
+		t.match, err = dateMatcher(op, value, timeField(name), now)
+	default:
+		return t, fmt.Errorf("unknown field %q in %q; quote the term to search for it as text", name, tok.text)
+	}
+	if err != nil {
+		return t, fmt.Errorf("invalid query term %q: %w", tok.text, err)
+	}
+	return t, nil
+}
+
+// containsText matches tasks whose title or description contains text
+func containsText(text string) func(*models.Task) bool {
+	needle := strings.ToLower(text)
+	return func(task *models.Task) bool {
+		return strings.Contains(strings.ToLower(task.Title), needle) ||
+			strings.Contains(strings.ToLower(task.Description), needle)
+	}
+}
+
+func statusMatcher(op, value string) (func(*models.Task) bool, error) {
+	if op != ":" && op != "!=" {
+		return nil, fmt.Errorf("status takes : or !=")
+	}
+	var match func(*models.Task) bool
+	switch strings.ToLower(value) {
+	case "open":
+		match = func(task *models.Task) bool {
+			return task.Status != models.TaskStatusCompleted && task.Status != models.TaskStatusCancelled
+		}
+	case "done":
+		match = func(task *models.Task) bool { return task.Status == models.TaskStatusCompleted }
+	default:
+		status, ok := parseStatus(value)
+		if !ok {
+			return nil, fmt.Errorf("unknown status %q, want pending, active, paused, completed, cancelled or open", value)
+		}
+		match = func(task *models.Task) bool { return task.Status == status }
+	}
+	if op == "!=" {
+		return not(match), nil
+	}
+	return match, nil
+}
+
+func parseStatus(value string) (models.TaskStatus, bool) {
+	for status := models.TaskStatusPending; status <= models.TaskStatusPaused; status++ {
+		if strings.EqualFold(status.String(), value) {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// stringMatcher matches tasks where one of the values of field equals value
+func stringMatcher(op, value string, field func(*models.Task) []string) (func(*models.Task) bool, error) {
+	if op != ":" && op != "!=" {
+		return nil, fmt.Errorf("takes : or !=")
+	}
+	match := func(task *models.Task) bool {
+		for _, v := range field(task) {
+			if strings.EqualFold(v, value) {
+				return true
+			}
+		}
+		return false
+	}
+	if op == "!=" {
+		return not(match), nil
+	}
+	return match, nil
+}
+
+func intMatcher(op, value string, field func(*models.Task) int) (func(*models.Task) bool, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(value, "#"))
+	if err != nil {
+		return nil, fmt.Errorf("want a number")
+	}
+	return func(task *models.Task) bool { return compare(op, field(task)-n) }, nil
+}
+
+func durationMatcher(op, value string) (func(*models.Task) bool, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("want a duration such as 45m or 1h30m")
+	}
+	return func(task *models.Task) bool {
+		return compare(op, int(task.EstimatedDuration-d))
+	}, nil
+}
+
+// compare applies op to the sign of the difference between a task's value
+// and the value of the term
+func compare(op string, diff int) bool {
+	switch op {
+	case "!=":
+		return diff != 0
+	case "<":
+		return diff < 0
+	case "<=":
+		return diff <= 0
+	case ">":
+		return diff > 0
+	case ">=":
+		return diff >= 0
+	}
+	return diff == 0
+}
+
+func dateMatcher(op, value string, field func(*models.Task) *time.Time, now time.Time) (func(*models.Task) bool, error) {
+	switch strings.ToLower(value) {
+	case "none":
+		if op != ":" && op != "!=" {
+			return nil, fmt.Errorf("none takes : or !=")
+		}
+		match := func(task *models.Task) bool { return field(task) == nil }
+		if op == "!=" {
+			return not(match), nil
+		}
+		return match, nil
+	case "overdue":
+		if op != ":" {
+			return nil, fmt.Errorf("overdue takes :")
+		}
+		return func(task *models.Task) bool { return task.IsOverdue(now) }, nil
+	}
+
+	start, end, err := Period(value, now)
+	if err != nil {
+		return nil, err
+	}
+	return func(task *models.Task) bool {
+		t := field(task)
+		if t == nil {
+			return false
+		}
+		switch op {
+		case "!=":
+			return t.Before(start) || !t.Before(end)
+		case "<":
+			return t.Before(start)
+		case "<=":
+			return t.Before(end)
+		case ">":
+			return !t.Before(end)
+		case ">=":
+			return !t.Before(start)
+		}
+		return !t.Before(start) && t.Before(end)
+	}, nil
+}
+
+// Period returns the start and end of the period named by value, relative
+// to now: today, yesterday, this-week, last-week, this-month, last-month,
+// this-year, or a single day as understood by models.ParseDate. Weeks
+// start on Monday.
+func Period(value string, now time.Time) (time.Time, time.Time, error) {
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	firstOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+
+	switch strings.ToLower(value) {
+	case "this-week":
+		return monday, monday.AddDate(0, 0, 7), nil
+	case "last-week":
+		return monday.AddDate(0, 0, -7), monday, nil
+	case "this-month":
+		return firstOfMonth, firstOfMonth.AddDate(0, 1, 0), nil
+	case "last-month":
+		return firstOfMonth.AddDate(0, -1, 0), firstOfMonth, nil
+	case "this-year":
+		firstOfYear := time.Date(year, 1, 1, 0, 0, 0, 0, now.Location())
+		return firstOfYear, firstOfYear.AddDate(1, 0, 0), nil
+	}
+
+	date, err := models.ParseDate(value, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("want this-week, last-week, this-month, last-month, this-year or a day: %w", err)
+	}
+	return date, date.AddDate(0, 0, 1), nil
+}
+
+func not(match func(*models.Task) bool) func(*models.Task) bool {
+	return func(task *models.Task) bool { return !match(task) }
+}
+
+// intField returns the accessor of a numeric field
+func intField(name string) func(*models.Task) int {
+	switch name {
+	case "id":
+		return func(task *models.Task) int { return task.ShortID }
+	case "work", "score.work":
+		return func(task *models.Task) int { return task.Score.Work }
+	case "play", "score.play":
+		return func(task *models.Task) int { return task.Score.Play }
+	case "learn", "score.learn":
+		return func(task *models.Task) int { return task.Score.Learn }
+	}
+	return func(task *models.Task) int { return task.Score.Total() }
+}
+
+// timeField returns the accessor of a date field
+func timeField(name string) func(*models.Task) *time.Time {
+	switch name {
+	case "created":
+		return func(task *models.Task) *time.Time { return &task.CreatedAt }
+	case "updated":
+		return func(task *models.Task) *time.Time { return &task.UpdatedAt }
+	case "completed":
+		return func(task *models.Task) *time.Time { return task.CompletedAt }
+	}
+	return func(task *models.Task) *time.Time { return task.DueDate }
+}
@@ -0,0 +1,176 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+// A Wednesday afternoon
+var now = time.Date(2024, 3, 6, 15, 30, 0, 0, time.Local)
+
+func testTasks() []*models.Task {
+	day := func(d int) *time.Time {
+		t := time.Date(2024, 3, d, 10, 0, 0, 0, time.Local)
+		return &t
+	}
+	due := func(d int) *time.Time {
+		t := time.Date(2024, 3, d, 0, 0, 0, 0, time.Local)
+		return &t
+	}
+	return []*models.Task{
+		{
+			ShortID: 1, Title: "Fix memory leak", Description: "In the API server", Status: models.TaskStatusActive,
+			Score: models.Score{Work: 5, Learn: 2}, Tags: []string{"api", "bug"}, CanonicalHour: "Prime",
+			EstimatedDuration: time.Hour, CreatedAt: *day(4), UpdatedAt: *day(6), DueDate: due(5),
+		},
+		{
+			ShortID: 2, Title: "Read Go spec", Status: models.TaskStatusPending,
+			Score: models.Score{Learn: 4, Play: 1}, Tags: []string{"go"}, CanonicalHour: "Vespers",
+			EstimatedDuration: 30 * time.Minute, CreatedAt: *day(6), UpdatedAt: *day(6), DueDate: due(8),
+		},
+		{
+			ShortID: 3, Title: "Deploy API", Status: models.TaskStatusCompleted,
+			Score: models.Score{Work: 3}, Tags: []string{"API"}, CanonicalHour: "prime",
+			CreatedAt: *day(1), UpdatedAt: *day(2), CompletedAt: day(2),
+		},
+		{
+			ShortID: 4, Title: "Walk", Description: "No memory of leaks", Status: models.TaskStatusPaused,
+			Score: models.Score{Play: 4}, CreatedAt: *day(5), UpdatedAt: *day(5),
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []int
+	}{
+		{expr: "", want: []int{1, 2, 3, 4}},
+		{expr: "status:pending", want: []int{2}},
+		{expr: "status:open", want: []int{1, 2, 4}},
+		{expr: "-status:open", want: []int{3}},
+		{expr: "status!=active", want: []int{2, 3, 4}},
+		{expr: "tag:api", want: []int{1, 3}},
+		{expr: "tag:api hour:PRIME work>=4", want: []int{1}},
+		{expr: "score>4", want: []int{1, 2}},
+		{expr: "play=4", want: []int{4}},
+		{expr: "id<=2", want: []int{1, 2}},
+		{expr: "estimate>=45m", want: []int{1}},
+		{expr: "created:this-week", want: []int{1, 2, 4}},
+		{expr: "created:last-week", want: []int{3}},
+		{expr: "created:today", want: []int{2}},
+		{expr: "created<2024-03-05", want: []int{1, 3}},
+		{expr: "created>yesterday", want: []int{2}},
+		{expr: "completed:this-month", want: []int{3}},
+		{expr: "due:none", want: []int{3, 4}},
+		{expr: "due:overdue", want: []int{1}},
+		{expr: "due:fri", want: []int{2}},
+		{expr: "memory", want: []int{1, 4}},
+		{expr: `"memory leak"`, want: []int{1}},
+		{expr: `-'memory leak' memory`, want: []int{4}},
+		{expr: "title:api", want: []int{3}},
+		{expr: `title!=api "api"`, want: []int{1}},
+		{expr: `"status:open"`, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			q, err := Parse(tt.expr, now)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := shortIDs(q.Filter(testTasks())); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, expr := range []string{
+		"stauts:pending",
+		"status:later",
+		"status>pending",
+		"work>=lots",
+		"estimate<soon",
+		"created:someday",
+		"tag:",
+		"work!4",
+		`"unterminated`,
+		"due>overdue",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := Parse(expr, now); err == nil {
+				t.Errorf("Parse() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []int
+	}{
+		{name: "single argument with spaces is a phrase", args: []string{"memory leak"}, want: []int{1}},
+		{name: "argument with spaces among others is a phrase", args: []string{"status:open", "memory leak"}, want: []int{1}},
+		{name: "separate words", args: []string{"memory", "leak"}, want: []int{1, 4}},
+		{name: "single term", args: []string{"tag:api"}, want: []int{1, 3}},
+		{name: "quotes within an argument", args: []string{`-"memory leak"`, "memory"}, want: []int{4}},
+		{name: "several terms", args: []string{"tag:api", "-status:completed"}, want: []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseArgs(tt.args, now)
+			if err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+			if got := shortIDs(q.Filter(testTasks())); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeriod(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.Local)
+	}
+	tests := []struct {
+		value      string
+		start, end time.Time
+	}{
+		{value: "today", start: day(3, 6), end: day(3, 7)},
+		{value: "this-week", start: day(3, 4), end: day(3, 11)},
+		{value: "last-week", start: day(2, 26), end: day(3, 4)},
+		{value: "this-month", start: day(3, 1), end: day(4, 1)},
+		{value: "last-month", start: day(2, 1), end: day(3, 1)},
+		{value: "this-year", start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), end: time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)},
+		{value: "2024-03-15", start: day(3, 15), end: day(3, 16)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			start, end, err := Period(tt.value, now)
+			if err != nil {
+				t.Fatalf("Period() error = %v", err)
+			}
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("Period() = %v - %v, want %v - %v", start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func shortIDs(tasks []*models.Task) []int {
+	var ids []int
+	for _, task := range tasks {
+		ids = append(ids, task.ShortID)
+	}
+	return ids
+}
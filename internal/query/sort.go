@@ -0,0 +1,134 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+// SortKey orders tasks by one field
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// Sort orders tasks by its keys in turn
+type Sort []SortKey
+
+// sortFields compare two tasks by a field
+var sortFields = map[string]func(a, b *models.Task) int{
+	"id":          func(a, b *models.Task) int { return a.ShortID - b.ShortID },
+	"title":       compareTitles,
+	"status":      func(a, b *models.Task) int { return statusRank(a.Status) - statusRank(b.Status) },
+	"work":        func(a, b *models.Task) int { return a.Score.Work - b.Score.Work },
+	"play":        func(a, b *models.Task) int { return a.Score.Play - b.Score.Play },
+	"learn":       func(a, b *models.Task) int { return a.Score.Learn - b.Score.Learn },
+	"score.total": func(a, b *models.Task) int { return a.Score.Total() - b.Score.Total() },
+	"estimate":    func(a, b *models.Task) int { return compareDurations(a.EstimatedDuration, b.EstimatedDuration) },
+	"created":     func(a, b *models.Task) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"updated":     func(a, b *models.Task) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+}
+
+// optionalSortFields return nil for tasks without a value, such as no due
+// date. Those sort last in either direction.
+var optionalSortFields = map[string]func(*models.Task) *time.Time{
+	"due":       func(task *models.Task) *time.Time { return task.DueDate },
+	"completed": func(task *models.Task) *time.Time { return task.CompletedAt },
+}
+
+// sortAliases are other names of sort fields
+var sortAliases = map[string]string{
+	"score":       "score.total",
+	"score.work":  "work",
+	"score.play":  "play",
+	"score.learn": "learn",
+}
+
+// ParseSort parses comma-separated sort fields, each optionally prefixed
+// with "-" for descending order, e.g. "score.total,-created". Fields are
+// id, title, status (in progress first), work, play, learn, score.total
+// (or score), estimate, created, updated, completed and due.
+func ParseSort(spec string) (Sort, error) {
+	var s Sort
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		key := SortKey{Field: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")}
+		if alias, ok := sortAliases[key.Field]; ok {
+			key.Field = alias
+		}
+		if sortFields[key.Field] == nil && optionalSortFields[key.Field] == nil {
+			return nil, fmt.Errorf("unknown sort field %q", key.Field)
+		}
+		s = append(s, key)
+	}
+	return s, nil
+}
+
+// Apply sorts tasks in place, keeping the order of tasks that compare equal
+func (s Sort) Apply(tasks []*models.Task) {
+	slices.SortStableFunc(tasks, func(a, b *models.Task) int {
+		for _, key := range s {
+			if c := key.compare(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+}
+
+func (k SortKey) compare(a, b *models.Task) int {
+	var c int
+	if field := optionalSortFields[k.Field]; field != nil {
+		ta, tb := field(a), field(b)
+		switch {
+		case ta == nil && tb == nil:
+			return 0
+		case ta == nil:
+			return 1
+		case tb == nil:
+			return -1
+		}
+		c = ta.Compare(*tb)
+	} else {
+		c = sortFields[k.Field](a, b)
+	}
+	if k.Desc {
+		return -c
+	}
+	return c
+}
+
+// statusRank orders tasks in progress first, then open, then finished
+func statusRank(status models.TaskStatus) int {
+	switch status {
+	case models.TaskStatusActive:
+		return 0
+	case models.TaskStatusPaused:
+		return 1
+	case models.TaskStatusPending:
+		return 2
+	case models.TaskStatusCompleted:
+		return 3
+	}
+	return 4
+}
+
+func compareTitles(a, b *models.Task) int {
+	return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+}
+
+func compareDurations(a, b time.Duration) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,47 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSort_Apply(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []int
+	}{
+		{spec: "", want: []int{1, 2, 3, 4}},
+		{spec: "-id", want: []int{4, 3, 2, 1}},
+		{spec: "score.total,-created", want: []int{3, 4, 2, 1}},
+		{spec: "-score", want: []int{1, 2, 4, 3}},
+		{spec: "status", want: []int{1, 4, 2, 3}},
+		{spec: "due", want: []int{1, 2, 3, 4}},
+		{spec: "-due", want: []int{2, 1, 3, 4}},
+		{spec: "play,title", want: []int{3, 1, 2, 4}},
+		{spec: " Title , ", want: []int{3, 1, 2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSort(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSort() error = %v", err)
+			}
+			tasks := testTasks()
+			s.Apply(tasks)
+			if got := shortIDs(tasks); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSort_Errors(t *testing.T) {
+	for _, spec := range []string{"priority", "score,-", "created,hour"} {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := ParseSort(spec); err == nil {
+				t.Errorf("ParseSort() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"qomoboro/internal/models"
+	"qomoboro/internal/storage"
+)
+
+// dateFormat is the day key used for comparing and storing daily stats
+const dateFormat = "2006-01-02"
+
+// ComputeDaily derives the statistics for a single day from the given tasks.
+// A task counts towards the day if it was created, scheduled, worked on or
+// completed on it. Time is credited from the time entries falling within the
+// day; each entry's share of the day goes to the canonical hour it was
+// started in, even when it runs past that hour. Scores are only credited
+// for tasks completed that day, bucketed into the canonical hour they
+// happened in. Interruptions logged that day are counted by the canonical
+// hour they happened in.
+func ComputeDaily(tasks []*models.Task, schedule *models.Schedule, date time.Time) *models.DailyStats {
+	day := date.Format(dateFormat)
+	dayStart := startOfDay(date)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	now := time.Now()
+
+	stats := &models.DailyStats{
+		Date:            dayStart,
+		HourlyBreakdown: make(map[string]models.Score),
+		TimeByHour:      make(map[string]time.Duration),
+
+		InterruptionsByHour: make(map[string]models.InterruptionStats),
+	}
+
+	for _, task := range tasks {
+		worked := false
+		for _, entry := range task.TimeEntries {
+			spent := entry.Overlap(dayStart, dayEnd, now)
+			if spent == 0 {
+				continue
+			}
+			worked = true
+			stats.TimeSpent += spent
+
+			hour := entry.CanonicalHour
+			if hour == "" && schedule != nil {
+				if h := schedule.GetCurrentHour(entry.Start); h != nil {
+					hour = h.Name
+				}
+			}
+			if hour != "" {
+				stats.TimeByHour[hour] += spent
+			}
+		}
+
+		for _, interruption := range task.Interruptions {
+			if interruption.At.Before(dayStart) || !interruption.At.Before(dayEnd) {
+				continue
+			}
+			worked = true
+			stats.Interruptions++
+
+			hour := interruption.CanonicalHour
+			if hour == "" && schedule != nil {
+				if h := schedule.GetCurrentHour(interruption.At); h != nil {
+					hour = h.Name
+				}
+			}
+			if hour != "" {
+				bucket := stats.InterruptionsByHour[hour]
+				bucket.Add(interruption)
+				stats.InterruptionsByHour[hour] = bucket
+			}
+		}
+
+		if !worked && !onDay(task, day) {
+			continue
+		}
+		stats.TotalTasks++
+
+		if task.Status != models.TaskStatusCompleted || task.CompletedAt == nil ||
+			task.CompletedAt.Format(dateFormat) != day {
+			continue
+		}
+
+		stats.CompletedTasks++
+		stats.TotalScore.Work += task.Score.Work
+		stats.TotalScore.Play += task.Score.Play
+		stats.TotalScore.Learn += task.Score.Learn
+		if len(task.TimeEntries) == 0 {
+			// Tasks tracked before time entries existed only have a total
+			stats.TimeSpent += task.ActualDuration
+		}
+
+		if schedule == nil {
+			continue
+		}
+		if hour := schedule.GetCurrentHour(*task.CompletedAt); hour != nil {
+			bucket := stats.HourlyBreakdown[hour.Name]
+			bucket.Work += task.Score.Work
+			bucket.Play += task.Score.Play
+			bucket.Learn += task.Score.Learn
+			stats.HourlyBreakdown[hour.Name] = bucket
+		}
+	}
+
+	if stats.CompletedTasks > 0 {
+		stats.AverageScore = models.Score{
+			Work:  stats.TotalScore.Work / stats.CompletedTasks,
+			Play:  stats.TotalScore.Play / stats.CompletedTasks,
+			Learn: stats.TotalScore.Learn / stats.CompletedTasks,
+		}
+	}
+
+	return stats
+}
+
+// RefreshDaily recomputes the stats snapshot of each given day from the
+// tasks in storage and saves it. A day given more than once is saved once.
+func RefreshDaily(store storage.Storage, dates ...time.Time) error {
+	tasks, err := store.ListTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks for stats: %w", err)
+	}
+
+	schedule, err := store.GetSchedule()
+	if err != nil {
+		return fmt.Errorf("failed to load schedule for stats: %w", err)
+	}
+
+	saved := make(map[string]bool)
+	for _, date := range dates {
+		day := date.Format(dateFormat)
+		if saved[day] {
+			continue
+		}
+		saved[day] = true
+		if err := store.SaveDailyStats(ComputeDaily(tasks, schedule, date)); err != nil {
+			return fmt.Errorf("failed to save daily stats: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// TaskDays returns the local days whose stats task counts towards, oldest
+// first: those it was created, scheduled, worked, interrupted or completed
+// on. Entries still running are counted up to now.
+func TaskDays(task *models.Task, now time.Time) []time.Time {
+	seen := make(map[string]bool)
+	var days []time.Time
+	add := func(t time.Time) {
+		if t.IsZero() {
+			return
+		}
+		day := startOfDay(t.Local())
+		if key := day.Format(dateFormat); !seen[key] {
+			seen[key] = true
+			days = append(days, day)
+		}
+	}
+
+	add(task.CreatedAt)
+	if task.ScheduledTime != nil {
+		add(*task.ScheduledTime)
+	}
+	if task.CompletedAt != nil {
+		add(*task.CompletedAt)
+	}
+	for _, entry := range task.TimeEntries {
+		end := now
+		if entry.End != nil {
+			end = *entry.End
+		}
+		add(entry.Start)
+		for day := startOfDay(entry.Start.Local()).AddDate(0, 0, 1); day.Before(end); day = day.AddDate(0, 0, 1) {
+			add(day)
+		}
+	}
+	for _, interruption := range task.Interruptions {
+		add(interruption.At)
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+// onDay reports whether the task was created, scheduled or completed on day
+func onDay(task *models.Task, day string) bool {
+	if task.CreatedAt.Format(dateFormat) == day {
+		return true
+	}
+	if task.ScheduledTime != nil && task.ScheduledTime.Format(dateFormat) == day {
+		return true
+	}
+	return task.CompletedAt != nil && task.CompletedAt.Format(dateFormat) == day
+}
+
+// startOfDay truncates t to local midnight
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,214 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+func TestComputeDaily(t *testing.T) {
+	schedule := models.GetDefaultSchedule()
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	at := func(hour, min int) *time.Time {
+		tm := day.Add(time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute)
+		return &tm
+	}
+
+	tasks := []*models.Task{
+		{
+			ID:             "prime",
+			Score:          models.Score{Work: 5, Play: 1, Learn: 3},
+			Status:         models.TaskStatusCompleted,
+			CreatedAt:      *at(8, 0),
+			CompletedAt:    at(10, 0),
+			ActualDuration: 45 * time.Minute,
+		},
+		{
+			ID:             "vespers",
+			Score:          models.Score{Work: 1, Play: 3, Learn: 5},
+			Status:         models.TaskStatusCompleted,
+			CreatedAt:      *at(9, 0),
+			CompletedAt:    at(17, 0),
+			ActualDuration: 30 * time.Minute,
+		},
+		{
+			ID:        "pending",
+			Score:     models.Score{Work: 4, Play: 4, Learn: 4},
+			Status:    models.TaskStatusPending,
+			CreatedAt: *at(11, 0),
+		},
+		{
+			ID:          "yesterday",
+			Score:       models.Score{Work: 2, Play: 2, Learn: 2},
+			Status:      models.TaskStatusCompleted,
+			CreatedAt:   day.AddDate(0, 0, -1),
+			CompletedAt: func() *time.Time { tm := day.AddDate(0, 0, -1); return &tm }(),
+		},
+	}
+
+	stats := ComputeDaily(tasks, &schedule, *at(12, 0))
+
+	if !stats.Date.Equal(day) {
+		t.Errorf("Date = %v, want %v", stats.Date, day)
+	}
+	if stats.TotalTasks != 3 {
+		t.Errorf("TotalTasks = %d, want 3", stats.TotalTasks)
+	}
+	if stats.CompletedTasks != 2 {
+		t.Errorf("CompletedTasks = %d, want 2", stats.CompletedTasks)
+	}
+	if want := (models.Score{Work: 6, Play: 4, Learn: 8}); stats.TotalScore != want {
+		t.Errorf("TotalScore = %+v, want %+v", stats.TotalScore, want)
+	}
+	if want := (models.Score{Work: 3, Play: 2, Learn: 4}); stats.AverageScore != want {
+		t.Errorf("AverageScore = %+v, want %+v", stats.AverageScore, want)
+	}
+	if stats.TimeSpent != 75*time.Minute {
+		t.Errorf("TimeSpent = %v, want %v", stats.TimeSpent, 75*time.Minute)
+	}
+	if got := stats.HourlyBreakdown["Prime"]; got != tasks[0].Score {
+		t.Errorf("HourlyBreakdown[Prime] = %+v, want %+v", got, tasks[0].Score)
+	}
+	if got := stats.HourlyBreakdown["Vespers"]; got != tasks[1].Score {
+		t.Errorf("HourlyBreakdown[Vespers] = %+v, want %+v", got, tasks[1].Score)
+	}
+	if len(stats.HourlyBreakdown) != 2 {
+		t.Errorf("HourlyBreakdown has %d hours, want 2", len(stats.HourlyBreakdown))
+	}
+}
+
+func TestComputeDaily_NoTasks(t *testing.T) {
+	stats := ComputeDaily(nil, nil, time.Now())
+
+	if stats.TotalTasks != 0 || stats.CompletedTasks != 0 {
+		t.Errorf("ComputeDaily(nil) = %+v, want empty stats", stats)
+	}
+	if stats.HourlyBreakdown == nil {
+		t.Errorf("HourlyBreakdown = nil, want empty map")
+	}
+}
+
+func TestComputeDaily_TimeEntries(t *testing.T) {
+	schedule := models.GetDefaultSchedule()
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	at := func(hour, min int) *time.Time {
+		tm := day.Add(time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute)
+		return &tm
+	}
+
+	tasks := []*models.Task{
+		{
+			// Created earlier and still pending, but worked on today
+			ID:        "worked",
+			Status:    models.TaskStatusPending,
+			CreatedAt: day.AddDate(0, 0, -3),
+			TimeEntries: []models.TimeEntry{
+				{Start: *at(-1, 0), End: at(1, 0)},                          // Spans midnight, only the last hour counts
+				{Start: *at(9, 30), End: at(10, 30)},                        // Prime
+				{Start: *at(16, 0), End: at(16, 45), CanonicalHour: "None"}, // Labelled when tracked
+			},
+		},
+		{
+			ID:          "untouched",
+			Status:      models.TaskStatusPending,
+			CreatedAt:   day.AddDate(0, 0, -3),
+			TimeEntries: []models.TimeEntry{{Start: *at(-20, 0), End: at(-19, 0)}},
+		},
+	}
+
+	stats := ComputeDaily(tasks, &schedule, day)
+
+	if stats.TotalTasks != 1 {
+		t.Errorf("TotalTasks = %d, want 1", stats.TotalTasks)
+	}
+	if want := 2*time.Hour + 45*time.Minute; stats.TimeSpent != want {
+		t.Errorf("TimeSpent = %v, want %v", stats.TimeSpent, want)
+	}
+	want := map[string]time.Duration{
+		"Prime": time.Hour,
+		"None":  45 * time.Minute,
+	}
+	for hour, spent := range want {
+		if stats.TimeByHour[hour] != spent {
+			t.Errorf("TimeByHour[%s] = %v, want %v", hour, stats.TimeByHour[hour], spent)
+		}
+	}
+	if len(stats.TimeByHour) != len(want) {
+		t.Errorf("TimeByHour = %v, want %v", stats.TimeByHour, want)
+	}
+}
+
+func TestComputeDaily_Interruptions(t *testing.T) {
+	schedule := models.GetDefaultSchedule()
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	at := func(hour, min int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute)
+	}
+
+	tasks := []*models.Task{
+		{
+			// Created earlier and interrupted today
+			ID:        "interrupted",
+			Status:    models.TaskStatusPaused,
+			CreatedAt: day.AddDate(0, 0, -3),
+			Interruptions: []models.Interruption{
+				{At: at(-2, 0), Reason: "slack", Kind: models.InterruptionExternal},                        // Yesterday
+				{At: at(9, 30), Reason: "slack", Kind: models.InterruptionExternal},                        // Prime
+				{At: at(10, 0), Reason: "email", Kind: models.InterruptionInternal},                        // Prime
+				{At: at(10, 15), Reason: "slack", Kind: models.InterruptionExternal},                       // Prime
+				{At: at(16, 0), Reason: "phone", Kind: models.InterruptionExternal, CanonicalHour: "None"}, // Labelled when logged
+			},
+		},
+	}
+
+	stats := ComputeDaily(tasks, &schedule, day)
+
+	if stats.TotalTasks != 1 {
+		t.Errorf("TotalTasks = %d, want 1", stats.TotalTasks)
+	}
+	if stats.Interruptions != 4 {
+		t.Errorf("Interruptions = %d, want 4", stats.Interruptions)
+	}
+	prime := stats.InterruptionsByHour["Prime"]
+	if prime.Internal != 1 || prime.External != 2 {
+		t.Errorf("InterruptionsByHour[Prime] = %+v, want 1 internal and 2 external", prime)
+	}
+	if got := prime.TopReasons(1); len(got) != 1 || got[0] != "slack" {
+		t.Errorf("InterruptionsByHour[Prime].TopReasons(1) = %v, want [slack]", got)
+	}
+	if got := stats.InterruptionsByHour["None"].Total(); got != 1 {
+		t.Errorf("InterruptionsByHour[None].Total() = %d, want 1", got)
+	}
+	if len(stats.InterruptionsByHour) != 2 {
+		t.Errorf("InterruptionsByHour has %d hours, want 2", len(stats.InterruptionsByHour))
+	}
+}
+
+func TestTaskDays(t *testing.T) {
+	day := func(d, hour int) time.Time {
+		return time.Date(2024, 3, d, hour, 0, 0, 0, time.Local)
+	}
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	task := &models.Task{
+		CreatedAt:   day(1, 9),
+		CompletedAt: ptr(day(9, 17)),
+		TimeEntries: []models.TimeEntry{
+			{Start: day(4, 23), End: ptr(day(5, 1))}, // Spans midnight
+			{Start: day(1, 10), End: ptr(day(1, 11))},
+			{Start: day(8, 22)}, // Still running
+		},
+		Interruptions: []models.Interruption{{At: day(6, 10)}},
+	}
+
+	var got []string
+	for _, d := range TaskDays(task, day(9, 1)) {
+		got = append(got, d.Format(dateFormat))
+	}
+	want := []string{"2024-03-01", "2024-03-04", "2024-03-05", "2024-03-06", "2024-03-08", "2024-03-09"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TaskDays() = %v, want %v", got, want)
+	}
+}
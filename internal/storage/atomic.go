@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// backupSuffix is appended to a data file to name its previous version
+const backupSuffix = ".bak"
+
+// writeFileAtomic replaces filename with data without ever leaving a
+// truncated file behind: the data is written to a temp file in the same
+// directory, fsynced and renamed over the original. When keepBackup is set
+// the version being replaced is kept as filename.bak, unless it is itself
+// unreadable, so a good backup is never overwritten by a corrupt primary.
+func writeFileAtomic(filename string, data []byte, keepBackup bool) error {
+	dir := filepath.Dir(filename)
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	// Clean up the temp file on any failure before the rename
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		return fmt.Errorf("failed to set permissions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if keepBackup {
+		if err := rotateBackup(filename); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpName, filename); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(filename), err)
+	}
+	committed = true
+
+	syncDir(dir)
+	return nil
+}
+
+// rotateBackup makes the current contents of filename available as
+// filename.bak. A hard link is used where possible so the primary file is
+// never missing; otherwise the file is copied.
+func rotateBackup(filename string) error {
+	current, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(filename), err)
+	}
+
+	// Never replace a good backup with a corrupt primary
+	if !json.Valid(current) {
+		return nil
+	}
+
+	backup := filename + backupSuffix
+	if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old backup: %w", err)
+	}
+	if err := os.Link(filename, backup); err == nil {
+		return nil
+	}
+
+	if err := os.WriteFile(backup, current, 0644); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// syncDir flushes directory metadata so a completed rename survives a crash.
+// Errors are ignored because not every platform supports syncing directories.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
@@ -0,0 +1,296 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupFormatVersion is the archive layout version written into manifests
+const BackupFormatVersion = 1
+
+// Backup labels recorded in the manifest
+const (
+	BackupLabelManual     = "manual"
+	BackupLabelPreRestore = "pre-restore"
+	BackupLabelAuto       = "auto" // Taken before the first write of the day
+)
+
+const (
+	backupManifestName = "manifest.json"
+	archivePrefix      = "qomoboro_"
+	archiveSuffix      = ".tar.gz"
+	backupIDFormat     = "20060102_150405"
+)
+
+// BackupManifest describes the contents of a backup archive
+type BackupManifest struct {
+	FormatVersion int          `json:"format_version" yaml:"format_version"`
+	SchemaVersion int          `json:"schema_version" yaml:"schema_version"`
+	Backend       string       `json:"backend" yaml:"backend"`
+	Label         string       `json:"label,omitempty" yaml:"label,omitempty"`
+	CreatedAt     time.Time    `json:"created_at" yaml:"created_at"`
+	TaskCount     int          `json:"task_count" yaml:"task_count"`
+	Files         []BackupFile `json:"files" yaml:"files"`
+}
+
+// BackupFile is a single file stored in a backup archive
+type BackupFile struct {
+	Name   string `json:"name" yaml:"name"`
+	Size   int64  `json:"size" yaml:"size"`
+	SHA256 string `json:"sha256" yaml:"sha256"`
+}
+
+// BackupInfo identifies a backup archive on disk
+type BackupInfo struct {
+	ID       string          `json:"id" yaml:"id"`
+	Path     string          `json:"path" yaml:"path"`
+	Size     int64           `json:"size" yaml:"size"`
+	Manifest *BackupManifest `json:"manifest" yaml:"manifest"`
+}
+
+// backupEntry is a file to be written into an archive
+type backupEntry struct {
+	name string
+	data []byte
+}
+
+// backupsDir returns the directory holding backup archives
+func backupsDir(dataDir string) string {
+	return filepath.Join(dataDir, "backups")
+}
+
+// writeBackupArchive writes entries and their manifest as a gzipped tar into
+// the backups directory. The manifest is the first entry so listing backups
+// only needs to read the head of each archive.
+func writeBackupArchive(dataDir string, manifest BackupManifest, entries []backupEntry) (*BackupInfo, error) {
+	dir := backupsDir(dataDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	manifest.FormatVersion = BackupFormatVersion
+	manifest.SchemaVersion = CurrentSchemaVersion
+	manifest.Files = make([]BackupFile, 0, len(entries))
+	for _, entry := range entries {
+		sum := sha256.Sum256(entry.data)
+		manifest.Files = append(manifest.Files, BackupFile{
+			Name:   entry.name,
+			Size:   int64(len(entry.data)),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	all := append([]backupEntry{{name: backupManifestName, data: manifestData}}, entries...)
+	for _, entry := range all {
+		header := &tar.Header{
+			Name:    entry.name,
+			Mode:    0644,
+			Size:    int64(len(entry.data)),
+			ModTime: manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to write archive: %w", err)
+		}
+		if _, err := tw.Write(entry.data); err != nil {
+			return nil, fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	id := uniqueBackupID(dir, manifest.CreatedAt)
+	path := filepath.Join(dir, archivePrefix+id+archiveSuffix)
+	if err := writeFileAtomic(path, buf.Bytes(), false); err != nil {
+		return nil, fmt.Errorf("failed to save archive: %w", err)
+	}
+
+	return &BackupInfo{
+		ID:       id,
+		Path:     path,
+		Size:     int64(buf.Len()),
+		Manifest: &manifest,
+	}, nil
+}
+
+// uniqueBackupID derives a backup ID from its creation time, adding a
+// counter when several backups are taken within the same second
+func uniqueBackupID(dir string, createdAt time.Time) string {
+	base := createdAt.Format(backupIDFormat)
+	id := base
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(dir, archivePrefix+id+archiveSuffix)); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// readBackupArchive reads an archive and returns its manifest and files.
+// With manifestOnly set it stops after the manifest.
+func readBackupArchive(path string, manifestOnly bool) (*BackupManifest, map[string][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	var manifest *BackupManifest
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("corrupt archive: %w", err)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("corrupt archive: %w", err)
+		}
+
+		if header.Name == backupManifestName {
+			manifest = &BackupManifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return nil, nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+			if manifestOnly {
+				return manifest, nil, nil
+			}
+			continue
+		}
+		files[header.Name] = data
+	}
+
+	if manifest == nil {
+		return nil, nil, fmt.Errorf("archive has no %s", backupManifestName)
+	}
+	return manifest, files, nil
+}
+
+// verifyBackupArchive reads an archive and checks every file against the
+// checksums recorded in its manifest
+func verifyBackupArchive(path string) (*BackupManifest, map[string][]byte, error) {
+	manifest, files, err := readBackupArchive(path, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if manifest.FormatVersion > BackupFormatVersion {
+		return nil, nil, fmt.Errorf("archive format version %d is newer than supported version %d",
+			manifest.FormatVersion, BackupFormatVersion)
+	}
+
+	for _, f := range manifest.Files {
+		data, ok := files[f.Name]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s is listed in the manifest but missing", f.Name)
+		}
+		sum := sha256.Sum256(data)
+		if int64(len(data)) != f.Size || hex.EncodeToString(sum[:]) != f.SHA256 {
+			return nil, nil, fmt.Errorf("%s does not match its checksum", f.Name)
+		}
+	}
+	if len(files) != len(manifest.Files) {
+		return nil, nil, fmt.Errorf("archive contains files not listed in the manifest")
+	}
+
+	return manifest, files, nil
+}
+
+// listBackupArchives returns all backup archives, oldest first. Archives
+// whose manifest cannot be read are still listed with a nil Manifest.
+func listBackupArchives(dataDir string) ([]*BackupInfo, error) {
+	entries, err := os.ReadDir(backupsDir(dataDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*BackupInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	backups := make([]*BackupInfo, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, archivePrefix) || !strings.HasSuffix(name, archiveSuffix) {
+			continue
+		}
+
+		info := &BackupInfo{
+			ID:   strings.TrimSuffix(strings.TrimPrefix(name, archivePrefix), archiveSuffix),
+			Path: filepath.Join(backupsDir(dataDir), name),
+		}
+		if stat, err := entry.Info(); err == nil {
+			info.Size = stat.Size()
+		}
+		if manifest, _, err := readBackupArchive(info.Path, true); err == nil {
+			info.Manifest = manifest
+		}
+		backups = append(backups, info)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ID < backups[j].ID
+	})
+
+	return backups, nil
+}
+
+// findBackupArchive resolves a backup by exact ID or unique ID prefix
+func findBackupArchive(dataDir, id string) (*BackupInfo, error) {
+	backups, err := listBackupArchives(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*BackupInfo
+	for _, backup := range backups {
+		if backup.ID == id {
+			return backup, nil
+		}
+		if strings.HasPrefix(backup.ID, id) {
+			matches = append(matches, backup)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("backup %s not found", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("backup ID %s is ambiguous (%d matches)", id, len(matches))
+	}
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+func TestFileStorage_BackupRestore(t *testing.T) {
+	store, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+
+	if err := store.CreateTask(newTestTask("a", time.Now(), models.TaskStatusPending)); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if err := store.SaveDailyStats(&models.DailyStats{Date: time.Now(), TotalTasks: 1}); err != nil {
+		t.Fatalf("SaveDailyStats() error = %v", err)
+	}
+
+	backup, err := store.CreateBackup(BackupLabelManual)
+	if err != nil {
+		t.Fatalf("CreateBackup() error = %v", err)
+	}
+	if backup.Manifest.TaskCount != 1 || backup.Manifest.Backend != backendFile {
+		t.Errorf("CreateBackup() manifest = %+v, want 1 task from file backend", backup.Manifest)
+	}
+	if len(backup.Manifest.Files) != 4 {
+		t.Errorf("CreateBackup() archived %d files, want tasks, schedule, history and one stats file", len(backup.Manifest.Files))
+	}
+
+	if _, err := store.VerifyBackup(backup.ID); err != nil {
+		t.Errorf("VerifyBackup() error = %v", err)
+	}
+
+	// Change the data after the backup was taken
+	if err := store.DeleteTask("a"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if err := store.CreateTask(newTestTask("b", time.Now(), models.TaskStatusPending)); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	safety, err := store.RestoreBackup(backup.ID[:8])
+	if err != nil {
+		t.Fatalf("RestoreBackup() error = %v", err)
+	}
+	if safety == nil || safety.Manifest.Label != BackupLabelPreRestore {
+		t.Errorf("RestoreBackup() safety backup = %+v, want pre-restore backup", safety)
+	}
+
+	tasks, err := store.ListTasks()
+	if err != nil {
+		t.Fatalf("ListTasks() error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "a" {
+		t.Errorf("ListTasks() after restore = %v, want task a", tasks)
+	}
+	if events, err := store.ListEvents(EventFilter{}); err != nil || len(events) != 1 {
+		t.Errorf("ListEvents() after restore = %d events, %v, want the creation of task a", len(events), err)
+	}
+
+	backups, err := store.ListBackups()
+	if err != nil {
+		t.Fatalf("ListBackups() error = %v", err)
+	}
+	if len(backups) != 2 {
+		t.Errorf("ListBackups() = %d backups, want original and pre-restore", len(backups))
+	}
+}
+
+func TestFileStorage_VerifyBackupDetectsCorruption(t *testing.T) {
+	store, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+
+	backup, err := store.CreateBackup(BackupLabelManual)
+	if err != nil {
+		t.Fatalf("CreateBackup() error = %v", err)
+	}
+
+	if err := os.WriteFile(backup.Path, []byte("not an archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.VerifyBackup(backup.ID); err == nil {
+		t.Errorf("VerifyBackup() on corrupt archive error = nil, want error")
+	}
+	if _, err := store.RestoreBackup(backup.ID); err == nil {
+		t.Errorf("RestoreBackup() from corrupt archive error = nil, want error")
+	}
+}
+
+func TestSQLiteStorage_BackupRestore(t *testing.T) {
+	store, err := NewSQLiteStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage() error = %v", err)
+	}
+	defer store.Close()
+
+	if err := store.CreateTask(newTestTask("a", time.Now(), models.TaskStatusPending)); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	backup, err := store.CreateBackup(BackupLabelManual)
+	if err != nil {
+		t.Fatalf("CreateBackup() error = %v", err)
+	}
+
+	if err := store.DeleteTask("a"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+
+	if _, err := store.RestoreBackup(backup.ID); err != nil {
+		t.Fatalf("RestoreBackup() error = %v", err)
+	}
+
+	if _, err := store.GetTask("a"); err != nil {
+		t.Errorf("GetTask() after restore error = %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+// HistoryFileName is the append-only task history of the file backend,
+// one JSON event per line
+const HistoryFileName = "history.jsonl"
+
+// EventFilter selects task events; the zero filter selects all of them
+type EventFilter struct {
+	TaskID string    // Only the events of this task, when set
+	From   time.Time // Only events at or after From, when set
+	To     time.Time // Only events before To, when set
+}
+
+// matches reports whether the filter selects event
+func (f EventFilter) matches(event *models.TaskEvent) bool {
+	if f.TaskID != "" && event.TaskID != f.TaskID {
+		return false
+	}
+	if !f.From.IsZero() && event.At.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !event.At.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// SetEventSource sets what the events of later changes are attributed to
+func (fs *FileStorage) SetEventSource(source string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.eventSource = source
+}
+
+// recordEventLocked appends the event for a change that was just saved.
+// Failures only warn, as the change itself has been made.
+func (fs *FileStorage) recordEventLocked(action models.EventAction, old, new *models.Task, at time.Time) {
+	event, err := models.NewTaskEvent(action, old, new, at, fs.eventSource)
+	if err == nil && event != nil {
+		err = appendEvent(fs.histFile, event)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record task history: %v\n", err)
+	}
+}
+
+// appendEvent writes event as one line at the end of the history file
+func appendEvent(filename string, event *models.TaskEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	// A single write keeps the line whole
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// appendEvents adds events recorded elsewhere to the end of the history
+func (fs *FileStorage) appendEvents(events []*models.TaskEvent) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lock.acquire()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, event := range events {
+		if err := appendEvent(fs.histFile, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListEvents returns the task events filter selects, oldest first. Lines
+// that cannot be decoded are skipped with a warning.
+func (fs *FileStorage) ListEvents(filter EventFilter) ([]*models.TaskEvent, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	file, err := os.Open(fs.histFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task history: %w", err)
+	}
+	defer file.Close()
+
+	var events []*models.TaskEvent
+	reader := bufio.NewReader(file)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to load task history: %w", err)
+		}
+		if line = bytes.TrimSpace(line); len(line) == 0 {
+			continue
+		}
+
+		var event models.TaskEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping unreadable line %d of %s: %v\n", lineNo, HistoryFileName, err)
+			continue
+		}
+		if filter.matches(&event) {
+			events = append(events, &event)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+func TestStorage_History(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(dir string) (Storage, error)
+	}{
+		{name: "file", open: func(dir string) (Storage, error) { return NewFileStorage(dir) }},
+		{name: "sqlite", open: func(dir string) (Storage, error) { return NewSQLiteStorage(dir) }},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			store, err := backend.open(t.TempDir())
+			if err != nil {
+				t.Fatalf("open() error = %v", err)
+			}
+			defer store.Close()
+
+			start := time.Now()
+			store.SetEventSource("cli add")
+			for _, id := range []string{"a", "b"} {
+				if err := store.CreateTask(newTestTask(id, start, models.TaskStatusPending)); err != nil {
+					t.Fatalf("CreateTask(%s) error = %v", id, err)
+				}
+			}
+
+			store.SetEventSource("cli edit")
+			task, _ := store.GetTask("a")
+			task.Score.Work = 5
+			if err := store.UpdateTask(task); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			// Saving an unchanged task records nothing
+			if err := store.UpdateTask(task); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			middle := time.Now()
+
+			store.SetEventSource("cli delete")
+			if err := store.DeleteTask("b"); err != nil {
+				t.Fatalf("DeleteTask() error = %v", err)
+			}
+
+			events, err := store.ListEvents(EventFilter{})
+			if err != nil {
+				t.Fatalf("ListEvents() error = %v", err)
+			}
+			var got []string
+			for _, event := range events {
+				got = append(got, event.TaskID+" "+string(event.Action)+" by "+event.Source)
+			}
+			want := []string{"a create by cli add", "b create by cli add", "a update by cli edit", "b delete by cli delete"}
+			if len(got) != len(want) {
+				t.Fatalf("ListEvents() = %q, want %q", got, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("event %d = %q, want %q", i, got[i], want[i])
+				}
+			}
+
+			update := events[2]
+			if len(update.Changes) != 1 || update.Changes[0].Field != "score.work" || update.Changes[0].New != float64(5) {
+				t.Errorf("update changes = %+v, want score.work set to 5", update.Changes)
+			}
+			if deleted := events[3]; deleted.Title != "Task b" || deleted.ShortID != 2 {
+				t.Errorf("delete event = %+v, want the title and short ID of the deleted task", deleted)
+			}
+
+			for _, tt := range []struct {
+				name   string
+				filter EventFilter
+				want   int
+			}{
+				{name: "by task", filter: EventFilter{TaskID: "a"}, want: 2},
+				{name: "from", filter: EventFilter{From: middle}, want: 1},
+				{name: "to", filter: EventFilter{To: middle}, want: 3},
+				{name: "task and range", filter: EventFilter{TaskID: "b", To: middle}, want: 1},
+				{name: "unknown task", filter: EventFilter{TaskID: "c"}, want: 0},
+			} {
+				events, err := store.ListEvents(tt.filter)
+				if err != nil {
+					t.Fatalf("ListEvents(%s) error = %v", tt.name, err)
+				}
+				if len(events) != tt.want {
+					t.Errorf("ListEvents(%s) returned %d events, want %d", tt.name, len(events), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFileStorage_HistorySkipsUnreadableLines(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+	if err := fs.CreateTask(newTestTask("a", time.Now(), models.TaskStatusPending)); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	// A line cut short by a crash, then a later event
+	file, err := os.OpenFile(filepath.Join(dir, HistoryFileName), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	file.WriteString(`{"at":"2024-03-06T10:00:00Z","act` + "\n")
+	file.Close()
+	if err := fs.DeleteTask("a"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+
+	events, err := fs.ListEvents(EventFilter{})
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	if len(events) != 2 || events[1].Action != models.EventDelete {
+		t.Errorf("ListEvents() returned %d events, want the create and the delete", len(events))
+	}
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"qomoboro/internal/models"
+)
+
+// ImportResult summarizes what was copied by ImportFileStorage
+type ImportResult struct {
+	Tasks        int
+	SkippedTasks int
+	Stats        int
+	Events       int
+}
+
+// historyImporter is implemented by backends that can take task history
+// recorded elsewhere
+type historyImporter interface {
+	appendEvents(events []*models.TaskEvent) error
+	insertTask(task *models.Task) error // CreateTask without recording an event
+}
+
+// ImportFileStorage copies tasks, schedule, daily stats and task history
+// from a JSON data directory into dst. Tasks whose ID already exists in dst
+// are skipped, so an interrupted import can simply be run again. The
+// history is only copied into a dst without any. Tasks whose history came
+// along are inserted without a second create event; the others record
+// their creation as usual.
+func ImportFileStorage(srcDir string, dst Storage) (*ImportResult, error) {
+	if _, err := os.Stat(filepath.Join(srcDir, "tasks.json")); err != nil {
+		return nil, fmt.Errorf("no JSON data found in %s: %w", srcDir, err)
+	}
+
+	src, err := NewFileStorage(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open JSON storage: %w", err)
+	}
+	defer src.Close()
+
+	result := &ImportResult{}
+
+	// Import tasks
+	tasks, err := src.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := dst.ListTasks()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, task := range existing {
+		known[task.ID] = true
+	}
+
+	// Import task history
+	importer, canImport := dst.(historyImporter)
+	hasHistory := make(map[string]bool)
+	if canImport {
+		recorded, err := dst.ListEvents(EventFilter{})
+		if err != nil {
+			return nil, err
+		}
+		events, err := src.ListEvents(EventFilter{})
+		if err != nil {
+			return nil, err
+		}
+		if len(recorded) == 0 && len(events) > 0 {
+			if err := importer.appendEvents(events); err != nil {
+				return result, fmt.Errorf("failed to import task history: %w", err)
+			}
+			result.Events = len(events)
+			recorded = events
+		}
+		for _, event := range recorded {
+			hasHistory[event.TaskID] = true
+		}
+	}
+
+	for _, task := range tasks {
+		if known[task.ID] {
+			result.SkippedTasks++
+			continue
+		}
+		create := dst.CreateTask
+		if hasHistory[task.ID] {
+			create = importer.insertTask
+		}
+		if err := create(task); err != nil {
+			return result, fmt.Errorf("failed to import task %s: %w", task.ID, err)
+		}
+		result.Tasks++
+	}
+
+	// Import schedule
+	schedule, err := src.GetSchedule()
+	if err != nil {
+		return result, err
+	}
+	if err := dst.SaveSchedule(schedule); err != nil {
+		return result, fmt.Errorf("failed to import schedule: %w", err)
+	}
+
+	// Import daily stats snapshots
+	entries, err := os.ReadDir(src.statsDir)
+	if err != nil {
+		return result, fmt.Errorf("failed to read stats directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		var stats models.DailyStats
+		if err := src.readJSON(filepath.Join(src.statsDir, entry.Name()), &stats); err != nil {
+			return result, fmt.Errorf("failed to read stats %s: %w", entry.Name(), err)
+		}
+		if err := dst.SaveDailyStats(&stats); err != nil {
+			return result, fmt.Errorf("failed to import stats %s: %w", entry.Name(), err)
+		}
+		result.Stats++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// LockFileName is the advisory lock file guarding the data directory
+const LockFileName = "qomoboro.lock"
+
+// DefaultLockTimeout is how long a writer waits for another process
+const DefaultLockTimeout = 5 * time.Second
+
+// lockPollInterval is the delay between attempts to take a held lock
+const lockPollInterval = 25 * time.Millisecond
+
+// ErrLockTimeout is returned when another process holds the data directory
+// lock for longer than the configured timeout
+var ErrLockTimeout = errors.New("data directory is locked by another qomoboro process")
+
+// fileLock is an exclusive advisory lock on a file, shared between processes
+type fileLock struct {
+	path    string
+	timeout time.Duration
+}
+
+// acquire takes the lock, waiting up to the timeout. The returned function
+// releases it.
+func (l *fileLock) acquire() (func(), error) {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(l.timeout)
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", l.path, err)
+		}
+		if locked {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("%w (waited %s for %s)", ErrLockTimeout, l.timeout, l.path)
+		}
+		time.Sleep(lockPollInterval)
+	}
+
+	return func() {
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+// CurrentSchemaVersion is the on-disk layout version written by this build.
+//
+// Version history:
+//
+//	1 - tasks.json is a bare array, schedule.json a bare object
+//	2 - both files are wrapped in a {"version": N, ...} envelope
+//	3 - tasks have short IDs, with the next one kept in tasks.json
+const CurrentSchemaVersion = 3
+
+// tasksDocument is the on-disk layout of tasks.json
+type tasksDocument struct {
+	Version     int            `json:"version"`
+	NextShortID int            `json:"next_short_id,omitempty"`
+	Tasks       []*models.Task `json:"tasks"`
+}
+
+// scheduleDocument is the on-disk layout of schedule.json
+type scheduleDocument struct {
+	Version  int              `json:"version"`
+	Schedule *models.Schedule `json:"schedule"`
+}
+
+// migration upgrades the data directory by one schema version. Migrations
+// work on the raw JSON rather than the current models, so they keep
+// working after the models change again.
+type migration struct {
+	version     int // schema version after this step
+	description string
+	up          func(fs *FileStorage) error
+}
+
+// migrations lists every upgrade step in order
+var migrations = []migration{
+	{
+		version:     2,
+		description: "wrap tasks.json and schedule.json in versioned envelopes",
+		up:          migrateToEnvelopes,
+	},
+	{
+		version:     3,
+		description: "number tasks with short IDs",
+		up:          migrateToShortIDs,
+	},
+}
+
+// checkSchemaVersion rejects data written by a newer qomoboro
+func checkSchemaVersion(file string, version int) error {
+	if version > CurrentSchemaVersion {
+		return fmt.Errorf("%s uses schema version %d, but this qomoboro only supports up to %d; please upgrade",
+			filepath.Base(file), version, CurrentSchemaVersion)
+	}
+	return nil
+}
+
+// schemaVersion detects the layout version of the data directory from
+// tasks.json. It returns 0 if there is no data yet.
+func (fs *FileStorage) schemaVersion() (int, error) {
+	data, err := os.ReadFile(fs.tasksFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read tasks file: %w", err)
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return 1, nil
+	}
+
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil || header.Version == 0 {
+		// Leave unreadable files to the normal load path and its backup
+		// fallback rather than guessing at a migration
+		return CurrentSchemaVersion, nil
+	}
+
+	return header.Version, nil
+}
+
+// migrate upgrades older on-disk layouts to CurrentSchemaVersion, copying
+// the pre-migration files into the backups directory first
+func (fs *FileStorage) migrate() error {
+	unlock, err := fs.lock.acquire()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fs.migrateLocked()
+}
+
+// migrateLocked runs migrate with the data directory lock already held
+func (fs *FileStorage) migrateLocked() error {
+	version, err := fs.schemaVersion()
+	if err != nil {
+		return err
+	}
+	if version == 0 || version == CurrentSchemaVersion {
+		return nil
+	}
+	if err := checkSchemaVersion(fs.tasksFile, version); err != nil {
+		return err
+	}
+
+	backupDir, err := fs.backupBeforeMigration(version)
+	if err != nil {
+		return fmt.Errorf("failed to back up data before migration: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.up(fs); err != nil {
+			return fmt.Errorf("migration to schema version %d (%s) failed: %w; original data is in %s",
+				m.version, m.description, err, backupDir)
+		}
+		version = m.version
+	}
+
+	// Each step left the layout before it in the .bak files, which the load
+	// path would misread as the current one; back up the migrated files.
+	// They are copied rather than linked so the backup survives the primary
+	// being damaged in place.
+	for _, file := range []string{fs.tasksFile, fs.schedFile} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed to back up migrated data: %w", err)
+		}
+		if err := writeFileAtomic(file+backupSuffix, data, false); err != nil {
+			return fmt.Errorf("failed to back up migrated data: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// backupBeforeMigration copies the data files into
+// backups/pre_migration_v<version>_<timestamp>/ and returns that directory
+func (fs *FileStorage) backupBeforeMigration(version int) (string, error) {
+	timestamp := time.Now().Format("20060102_150405")
+	backupDir := filepath.Join(fs.dataDir, "backups", fmt.Sprintf("pre_migration_v%d_%s", version, timestamp))
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return "", err
+	}
+
+	for _, file := range []string{fs.tasksFile, fs.schedFile} {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		}
+		if err := fs.copyFile(file, filepath.Join(backupDir, filepath.Base(file))); err != nil {
+			return "", err
+		}
+	}
+
+	return backupDir, nil
+}
+
+// migrateToEnvelopes upgrades schema version 1 to 2 by wrapping the bare
+// task array and schedule object in versioned envelopes
+func migrateToEnvelopes(fs *FileStorage) error {
+	var tasks json.RawMessage
+	if err := fs.readJSON(fs.tasksFile, &tasks); err != nil {
+		return fmt.Errorf("failed to read tasks: %w", err)
+	}
+	if err := fs.writeJSON(fs.tasksFile, map[string]interface{}{
+		"version": 2,
+		"tasks":   tasks,
+	}); err != nil {
+		return fmt.Errorf("failed to write tasks: %w", err)
+	}
+
+	var schedule json.RawMessage
+	if err := fs.readJSON(fs.schedFile, &schedule); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read schedule: %w", err)
+	}
+	if err := fs.writeJSON(fs.schedFile, map[string]interface{}{
+		"version":  2,
+		"schedule": schedule,
+	}); err != nil {
+		return fmt.Errorf("failed to write schedule: %w", err)
+	}
+
+	return nil
+}
+
+// migrateToShortIDs upgrades schema version 2 to 3 by numbering the tasks
+// from 1 in the order they are stored, which is the order they were created
+func migrateToShortIDs(fs *FileStorage) error {
+	var doc struct {
+		Tasks []map[string]json.RawMessage `json:"tasks"`
+	}
+	if err := fs.readJSON(fs.tasksFile, &doc); err != nil {
+		return fmt.Errorf("failed to read tasks: %w", err)
+	}
+
+	for i, task := range doc.Tasks {
+		task["short_id"] = json.RawMessage(fmt.Sprint(i + 1))
+	}
+	if doc.Tasks == nil {
+		doc.Tasks = make([]map[string]json.RawMessage, 0)
+	}
+
+	if err := fs.writeJSON(fs.tasksFile, map[string]interface{}{
+		"version":       3,
+		"next_short_id": len(doc.Tasks) + 1,
+		"tasks":         doc.Tasks,
+	}); err != nil {
+		return fmt.Errorf("failed to write tasks: %w", err)
+	}
+
+	// schedule.json has not changed and is rewritten at version 3 when
+	// next saved
+	return nil
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	legacyTasksJSON = `[
+  {"id": "task_1", "title": "Fix bug", "score": {"work": 4, "play": 1, "learn": 3}, "status": 2,
+   "estimated_duration": 0, "created_at": "2024-03-04T09:00:00Z", "updated_at": "2024-03-04T10:00:00Z"}
+]`
+	legacyScheduleJSON = `{"name": "Custom", "hours": [{"name": "Prime", "start_time": "09:00", "end_time": "12:00", "duration": 10800000000000}]}`
+)
+
+// writeLegacyData lays out a schema version 1 data directory
+func writeLegacyData(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "stats"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tasks.json"), []byte(legacyTasksJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "schedule.json"), []byte(legacyScheduleJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileStorage_SchemaVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks string
+		want  int
+	}{
+		{name: "no data", tasks: "", want: 0},
+		{name: "bare array", tasks: "  [\n]", want: 1},
+		{name: "envelope", tasks: `{"version": 2, "tasks": []}`, want: 2},
+		{name: "newer envelope", tasks: `{"version": 7, "tasks": []}`, want: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			fs := &FileStorage{tasksFile: filepath.Join(dir, "tasks.json")}
+			if tt.tasks != "" {
+				if err := os.WriteFile(fs.tasksFile, []byte(tt.tasks), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := fs.schemaVersion()
+			if err != nil {
+				t.Fatalf("schemaVersion() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("schemaVersion() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateToEnvelopes(t *testing.T) {
+	dir := t.TempDir()
+	writeLegacyData(t, dir)
+	fs := &FileStorage{
+		dataDir:   dir,
+		tasksFile: filepath.Join(dir, "tasks.json"),
+		schedFile: filepath.Join(dir, "schedule.json"),
+	}
+
+	if err := migrateToEnvelopes(fs); err != nil {
+		t.Fatalf("migrateToEnvelopes() error = %v", err)
+	}
+
+	var tasks tasksDocument
+	if err := fs.readJSON(fs.tasksFile, &tasks); err != nil {
+		t.Fatalf("reading migrated tasks error = %v", err)
+	}
+	if tasks.Version != 2 {
+		t.Errorf("tasks version = %d, want 2", tasks.Version)
+	}
+	if len(tasks.Tasks) != 1 || tasks.Tasks[0].Title != "Fix bug" || tasks.Tasks[0].Score.Work != 4 {
+		t.Errorf("migrated tasks = %+v, want the legacy task", tasks.Tasks)
+	}
+
+	var schedule scheduleDocument
+	if err := fs.readJSON(fs.schedFile, &schedule); err != nil {
+		t.Fatalf("reading migrated schedule error = %v", err)
+	}
+	if schedule.Version != 2 {
+		t.Errorf("schedule version = %d, want 2", schedule.Version)
+	}
+	if schedule.Schedule == nil || schedule.Schedule.Name != "Custom" || len(schedule.Schedule.Hours) != 1 {
+		t.Errorf("migrated schedule = %+v, want the legacy schedule", schedule.Schedule)
+	}
+}
+
+func TestNewFileStorage_MigratesLegacyData(t *testing.T) {
+	dir := t.TempDir()
+	writeLegacyData(t, dir)
+
+	store, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+
+	tasks, err := store.ListTasks()
+	if err != nil {
+		t.Fatalf("ListTasks() error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "task_1" {
+		t.Errorf("ListTasks() = %v, want migrated task", tasks)
+	}
+
+	schedule, err := store.GetSchedule()
+	if err != nil {
+		t.Fatalf("GetSchedule() error = %v", err)
+	}
+	if schedule.Name != "Custom" {
+		t.Errorf("GetSchedule().Name = %q, want %q", schedule.Name, "Custom")
+	}
+
+	// The untouched legacy files must have been backed up first
+	matches, _ := filepath.Glob(filepath.Join(dir, "backups", "pre_migration_v1_*", "tasks.json"))
+	if len(matches) != 1 {
+		t.Fatalf("pre-migration backups = %v, want exactly one", matches)
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != legacyTasksJSON {
+		t.Errorf("pre-migration backup does not match the original tasks.json")
+	}
+
+	// A damaged tasks.json falls back to a backup in the migrated layout
+	if err := os.WriteFile(filepath.Join(dir, "tasks.json"), []byte(`{"version": 3, "tasks": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tasks, err = store.ListTasks()
+	if err != nil {
+		t.Fatalf("ListTasks() from backup error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "task_1" || tasks[0].ShortID != 1 {
+		t.Errorf("ListTasks() from backup = %v, want the migrated task", tasks)
+	}
+
+	// Opening again must not migrate or back up a second time
+	if _, err := NewFileStorage(dir); err != nil {
+		t.Fatalf("NewFileStorage() second open error = %v", err)
+	}
+	matches, _ = filepath.Glob(filepath.Join(dir, "backups", "pre_migration_*"))
+	if len(matches) != 1 {
+		t.Errorf("pre-migration backups after reopen = %v, want exactly one", matches)
+	}
+}
+
+func TestNewFileStorage_RejectsNewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	future, _ := json.Marshal(map[string]interface{}{"version": CurrentSchemaVersion + 1, "tasks": []interface{}{}})
+	if err := os.WriteFile(filepath.Join(dir, "tasks.json"), future, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewFileStorage(dir)
+	if err == nil || !strings.Contains(err.Error(), "schema version") {
+		t.Errorf("NewFileStorage() error = %v, want schema version error", err)
+	}
+}
+
+func TestMigrations_AreOrdered(t *testing.T) {
+	last := 1
+	for _, m := range migrations {
+		if m.version != last+1 {
+			t.Errorf("migration %q targets version %d, want %d", m.description, m.version, last+1)
+		}
+		last = m.version
+	}
+	if last != CurrentSchemaVersion {
+		t.Errorf("last migration targets version %d, want CurrentSchemaVersion %d", last, CurrentSchemaVersion)
+	}
+}
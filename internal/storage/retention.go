@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy decides which backup archives are kept when pruning.
+// The newest backup of each of the last KeepDaily days, KeepWeekly ISO weeks
+// and KeepMonthly months is kept; a backup may satisfy several tiers. The
+// zero policy disables pruning.
+type RetentionPolicy struct {
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+}
+
+// DefaultRetentionPolicy returns a week of dailies, a month of weeklies and
+// half a year of monthlies
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		KeepDaily:   7,
+		KeepWeekly:  4,
+		KeepMonthly: 6,
+	}
+}
+
+// Enabled reports whether the policy prunes anything at all
+func (p RetentionPolicy) Enabled() bool {
+	return p.KeepDaily > 0 || p.KeepWeekly > 0 || p.KeepMonthly > 0
+}
+
+// BackupsToPrune returns the backups that policy does not keep, oldest
+// first. Every backup taken on the day of now is kept, so the day's
+// automatic and pre-restore backups survive later manual ones, as are the
+// newest backup and any backup whose creation time cannot be determined.
+func BackupsToPrune(backups []*BackupInfo, policy RetentionPolicy, now time.Time) []*BackupInfo {
+	if !policy.Enabled() {
+		return nil
+	}
+
+	// Walk newest first so each period keeps its latest backup
+	dated := make([]*BackupInfo, 0, len(backups))
+	for _, backup := range backups {
+		if _, ok := backupTime(backup); ok {
+			dated = append(dated, backup)
+		}
+	}
+	sort.SliceStable(dated, func(i, j int) bool {
+		ti, _ := backupTime(dated[i])
+		tj, _ := backupTime(dated[j])
+		return ti.After(tj)
+	})
+	if len(dated) == 0 {
+		return nil
+	}
+
+	keep := map[*BackupInfo]bool{dated[0]: true}
+	today := now.Local().Format("2006-01-02")
+	for _, backup := range dated {
+		if t, _ := backupTime(backup); t.Format("2006-01-02") == today {
+			keep[backup] = true
+		}
+	}
+
+	tiers := []struct {
+		count int
+		key   func(time.Time) string
+	}{
+		{policy.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{policy.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{policy.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, tier := range tiers {
+		seen := make(map[string]bool)
+		for _, backup := range dated {
+			t, _ := backupTime(backup)
+			key := tier.key(t)
+			if seen[key] {
+				continue
+			}
+			if len(seen) >= tier.count {
+				break
+			}
+			seen[key] = true
+			keep[backup] = true
+		}
+	}
+
+	var prune []*BackupInfo
+	for i := len(dated) - 1; i >= 0; i-- {
+		if !keep[dated[i]] {
+			prune = append(prune, dated[i])
+		}
+	}
+	return prune
+}
+
+// backupTime returns when a backup was taken in local time, from its
+// manifest or, failing that, from its ID
+func backupTime(backup *BackupInfo) (time.Time, bool) {
+	if backup.Manifest != nil && !backup.Manifest.CreatedAt.IsZero() {
+		return backup.Manifest.CreatedAt.Local(), true
+	}
+	if len(backup.ID) < len(backupIDFormat) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(backupIDFormat, backup.ID[:len(backupIDFormat)], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// pruneBackupArchives deletes the archives in dataDir that policy does not
+// keep and returns them
+func pruneBackupArchives(dataDir string, policy RetentionPolicy) ([]*BackupInfo, error) {
+	backups, err := listBackupArchives(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	pruned := make([]*BackupInfo, 0)
+	for _, backup := range BackupsToPrune(backups, policy, time.Now()) {
+		if err := os.Remove(backup.Path); err != nil && !os.IsNotExist(err) {
+			return pruned, fmt.Errorf("failed to remove backup %s: %w", backup.ID, err)
+		}
+		pruned = append(pruned, backup)
+	}
+
+	return pruned, nil
+}
+
+// autoBackupStamp names the file in the backups directory holding the day
+// of the last automatic backup, so checking for one does not have to open
+// every archive
+const autoBackupStamp = "last_auto_backup"
+
+// hasAutoBackupOn reports whether an automatic backup was already taken on
+// the day of now
+func hasAutoBackupOn(dataDir string, now time.Time) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(backupsDir(dataDir), autoBackupStamp))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read automatic backup stamp: %w", err)
+	}
+	return strings.TrimSpace(string(data)) == now.Local().Format("2006-01-02"), nil
+}
+
+// markAutoBackupOn records the day of now as that of the last automatic
+// backup
+func markAutoBackupOn(dataDir string, now time.Time) error {
+	day := now.Local().Format("2006-01-02") + "\n"
+	if err := os.WriteFile(filepath.Join(backupsDir(dataDir), autoBackupStamp), []byte(day), 0644); err != nil {
+		return fmt.Errorf("failed to write automatic backup stamp: %w", err)
+	}
+	return nil
+}
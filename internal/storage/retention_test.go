@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+// backupAt builds a listed backup taken at the given local time
+func backupAt(value string) *BackupInfo {
+	createdAt, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return &BackupInfo{
+		ID:       createdAt.Format(backupIDFormat),
+		Manifest: &BackupManifest{CreatedAt: createdAt},
+	}
+}
+
+func TestBackupsToPrune(t *testing.T) {
+	tests := []struct {
+		name    string
+		backups []string
+		policy  RetentionPolicy
+		now     string
+		want    []string // Creation times of the pruned backups, oldest first
+	}{
+		{
+			name:    "disabled policy keeps everything",
+			backups: []string{"2024-03-01 09:00", "2024-03-02 09:00"},
+			policy:  RetentionPolicy{},
+			now:     "2024-03-10 09:00",
+			want:    nil,
+		},
+		{
+			name:    "daily keeps newest per day",
+			backups: []string{"2024-03-01 09:00", "2024-03-01 18:00", "2024-03-02 09:00", "2024-03-03 09:00", "2024-03-03 12:00"},
+			policy:  RetentionPolicy{KeepDaily: 2},
+			now:     "2024-03-10 09:00",
+			want:    []string{"2024-03-01 09:00", "2024-03-01 18:00", "2024-03-03 09:00"},
+		},
+		{
+			name: "weekly and monthly tiers reach further back",
+			backups: []string{
+				"2024-01-15 09:00", // Monday of ISO week 3, January
+				"2024-02-05 09:00", // Week 6, February
+				"2024-02-26 09:00", // Week 9
+				"2024-02-27 09:00", // Week 9
+				"2024-03-04 09:00", // Week 10, March
+			},
+			policy: RetentionPolicy{KeepDaily: 1, KeepWeekly: 2, KeepMonthly: 3},
+			now:    "2024-03-10 09:00",
+			want:   []string{"2024-02-05 09:00", "2024-02-26 09:00"},
+		},
+		{
+			name:    "newest backup is always kept",
+			backups: []string{"2024-03-01 09:00"},
+			policy:  RetentionPolicy{KeepWeekly: 1},
+			now:     "2024-09-01 09:00",
+			want:    nil,
+		},
+		{
+			name:    "all of today's backups are kept",
+			backups: []string{"2024-03-01 09:00", "2024-03-02 08:00", "2024-03-02 09:00", "2024-03-02 10:00"},
+			policy:  RetentionPolicy{KeepDaily: 1},
+			now:     "2024-03-02 11:00",
+			want:    []string{"2024-03-01 09:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var backups []*BackupInfo
+			for _, value := range tt.backups {
+				backups = append(backups, backupAt(value))
+			}
+
+			var got []string
+			now := backupAt(tt.now).Manifest.CreatedAt
+			for _, backup := range BackupsToPrune(backups, tt.policy, now) {
+				got = append(got, backup.Manifest.CreatedAt.Format("2006-01-02 15:04"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BackupsToPrune() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackupsToPrune_KeepsUndatedBackups(t *testing.T) {
+	backups := []*BackupInfo{
+		{ID: "not-a-timestamp"},
+		backupAt("2024-03-01 09:00"),
+		backupAt("2024-03-02 09:00"),
+	}
+
+	got := BackupsToPrune(backups, RetentionPolicy{KeepDaily: 1}, time.Now())
+	if len(got) != 1 || got[0] != backups[1] {
+		t.Errorf("BackupsToPrune() = %v, want only the older dated backup", got)
+	}
+}
+
+func TestFileStorage_AutoBackup(t *testing.T) {
+	dir := t.TempDir()
+	opts := DefaultFileStorageOptions()
+	opts.AutoBackup = true
+	store, err := NewFileStorageWithOptions(dir, opts)
+	if err != nil {
+		t.Fatalf("NewFileStorageWithOptions() error = %v", err)
+	}
+
+	for _, id := range []string{"a", "b"} {
+		if err := store.CreateTask(newTestTask(id, time.Now(), models.TaskStatusPending)); err != nil {
+			t.Fatalf("CreateTask() error = %v", err)
+		}
+	}
+
+	backups, err := store.ListBackups()
+	if err != nil {
+		t.Fatalf("ListBackups() error = %v", err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("ListBackups() = %d backups, want one automatic backup", len(backups))
+	}
+	if backups[0].Manifest.Label != BackupLabelAuto || backups[0].Manifest.TaskCount != 0 {
+		t.Errorf("automatic backup manifest = %+v, want auto backup of the data before the first write", backups[0].Manifest)
+	}
+	if done, err := hasAutoBackupOn(dir, time.Now()); err != nil || !done {
+		t.Errorf("hasAutoBackupOn() = %v, %v, want true once the backup is stamped", done, err)
+	}
+
+	// A second process opening the same directory finds today's backup
+	other, err := NewFileStorageWithOptions(dir, opts)
+	if err != nil {
+		t.Fatalf("NewFileStorageWithOptions() error = %v", err)
+	}
+	if err := other.DeleteTask("a"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if backups, _ := other.ListBackups(); len(backups) != 1 {
+		t.Errorf("ListBackups() after second process write = %d backups, want 1", len(backups))
+	}
+}
+
+func TestFileStorage_CreateBackupPrunes(t *testing.T) {
+	dir := t.TempDir()
+	opts := DefaultFileStorageOptions()
+	opts.Retention = RetentionPolicy{KeepDaily: 1}
+	store, err := NewFileStorageWithOptions(dir, opts)
+	if err != nil {
+		t.Fatalf("NewFileStorageWithOptions() error = %v", err)
+	}
+
+	stale, err := writeBackupArchive(dir, BackupManifest{
+		Backend:   backendFile,
+		Label:     BackupLabelManual,
+		CreatedAt: time.Date(2000, 1, 1, 9, 0, 0, 0, time.Local),
+	}, nil)
+	if err != nil {
+		t.Fatalf("writeBackupArchive() error = %v", err)
+	}
+
+	if _, err := store.CreateBackup(BackupLabelManual); err != nil {
+		t.Fatalf("CreateBackup() error = %v", err)
+	}
+
+	if _, err := os.Stat(stale.Path); !os.IsNotExist(err) {
+		t.Errorf("stale backup still exists after CreateBackup, want it pruned")
+	}
+	if backups, _ := store.ListBackups(); len(backups) != 1 {
+		t.Errorf("ListBackups() = %d backups, want 1", len(backups))
+	}
+}
@@ -0,0 +1,42 @@
+package storage
+
+import "qomoboro/internal/models"
+
+// assignShortID gives task a short ID before it is stored. A task that
+// already has one keeps it unless another task uses it, so imports and
+// restores keep the numbers users know. used holds the short IDs in use and
+// next the counter stored with the tasks; the counter for the following
+// task is returned. Numbers of deleted tasks are never handed out again.
+func assignShortID(task *models.Task, used []int, next int) int {
+	taken := make(map[int]bool, len(used))
+	for _, id := range used {
+		taken[id] = true
+		if id >= next {
+			next = id + 1
+		}
+	}
+	if next < 1 {
+		next = 1
+	}
+
+	if task.ShortID > 0 && !taken[task.ShortID] {
+		if task.ShortID >= next {
+			next = task.ShortID + 1
+		}
+		return next
+	}
+
+	task.ShortID = next
+	return next + 1
+}
+
+// shortIDs returns the short IDs used by tasks
+func shortIDs(tasks []*models.Task) []int {
+	ids := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		if task.ShortID > 0 {
+			ids = append(ids, task.ShortID)
+		}
+	}
+	return ids
+}
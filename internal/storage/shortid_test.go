@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+func TestAssignShortID(t *testing.T) {
+	tests := []struct {
+		name     string
+		preset   int
+		used     []int
+		next     int
+		want     int
+		wantNext int
+	}{
+		{name: "first task", want: 1, wantNext: 2},
+		{name: "counter", used: []int{1, 2}, next: 5, want: 5, wantNext: 6},
+		{name: "counter behind used IDs", used: []int{1, 7}, next: 3, want: 8, wantNext: 9},
+		{name: "free preset is kept", preset: 4, used: []int{1}, next: 2, want: 4, wantNext: 5},
+		{name: "free preset below counter", preset: 2, used: []int{1, 3}, next: 4, want: 2, wantNext: 4},
+		{name: "taken preset is replaced", preset: 1, used: []int{1}, next: 2, want: 2, wantNext: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &models.Task{ShortID: tt.preset}
+			next := assignShortID(task, tt.used, tt.next)
+			if task.ShortID != tt.want || next != tt.wantNext {
+				t.Errorf("assignShortID() = short ID %d, next %d, want %d, %d", task.ShortID, next, tt.want, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestStorage_ShortIDs(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(dir string) (Storage, error)
+	}{
+		{name: "file", open: func(dir string) (Storage, error) { return NewFileStorage(dir) }},
+		{name: "sqlite", open: func(dir string) (Storage, error) { return NewSQLiteStorage(dir) }},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := backend.open(dir)
+			if err != nil {
+				t.Fatalf("open() error = %v", err)
+			}
+
+			create := func(id string) *models.Task {
+				t.Helper()
+				task := newTestTask(id, time.Now(), models.TaskStatusPending)
+				if err := store.CreateTask(task); err != nil {
+					t.Fatalf("CreateTask(%s) error = %v", id, err)
+				}
+				got, err := store.GetTask(id)
+				if err != nil {
+					t.Fatalf("GetTask(%s) error = %v", id, err)
+				}
+				return got
+			}
+
+			for i, id := range []string{"a", "b", "c"} {
+				if got := create(id).ShortID; got != i+1 {
+					t.Errorf("short ID of task %s = %d, want %d", id, got, i+1)
+				}
+			}
+
+			// Numbers are not reused after a delete, nor after reopening
+			if err := store.DeleteTask("c"); err != nil {
+				t.Fatalf("DeleteTask() error = %v", err)
+			}
+			if got := create("d").ShortID; got != 4 {
+				t.Errorf("short ID after deleting the newest task = %d, want 4", got)
+			}
+			store.Close()
+			if store, err = backend.open(dir); err != nil {
+				t.Fatalf("reopen error = %v", err)
+			}
+			defer store.Close()
+			if got := create("e").ShortID; got != 5 {
+				t.Errorf("short ID after reopening = %d, want 5", got)
+			}
+
+			// Updates keep the short ID
+			task, _ := store.GetTask("a")
+			task.Complete()
+			if err := store.UpdateTask(task); err != nil {
+				t.Fatalf("UpdateTask() error = %v", err)
+			}
+			if got, _ := store.GetTask("a"); got.ShortID != 1 {
+				t.Errorf("short ID after update = %d, want 1", got.ShortID)
+			}
+		})
+	}
+}
+
+func TestNewFileStorage_NumbersLegacyTasks(t *testing.T) {
+	dir := t.TempDir()
+	writeLegacyData(t, dir)
+
+	store, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+
+	task, err := store.GetTask("task_1")
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if task.ShortID != 1 {
+		t.Errorf("migrated task short ID = %d, want 1", task.ShortID)
+	}
+
+	next := newTestTask("new", time.Now(), models.TaskStatusPending)
+	if err := store.CreateTask(next); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if next.ShortID != 2 {
+		t.Errorf("short ID after migration = %d, want 2", next.ShortID)
+	}
+}
+
+func TestNewSQLiteStorage_NumbersUnnumberedTasks(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewSQLiteStorage(dir)
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage() error = %v", err)
+	}
+
+	// Rows written before short IDs existed
+	for _, id := range []string{"old_a", "old_b"} {
+		if _, err := store.db.Exec(
+			`INSERT INTO tasks (id, status, created_at, created_day, data) VALUES (?, 0, '', '', ?)`,
+			id, `{"id": "`+id+`", "title": "Old", "status": 0}`,
+		); err != nil {
+			t.Fatalf("inserting unnumbered task error = %v", err)
+		}
+	}
+	store.Close()
+
+	store, err = NewSQLiteStorage(dir)
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage() reopen error = %v", err)
+	}
+	defer store.Close()
+
+	tasks, err := store.ListTasks()
+	if err != nil {
+		t.Fatalf("ListTasks() error = %v", err)
+	}
+	for i, task := range tasks {
+		if task.ShortID != i+1 {
+			t.Errorf("short ID of %s = %d, want %d", task.ID, task.ShortID, i+1)
+		}
+	}
+}
+
+func TestNewSQLiteStorage_MigratesShortIDColumn(t *testing.T) {
+	dir := t.TempDir()
+	db, err := openSQLiteDB(filepath.Join(dir, SQLiteDatabaseFile))
+	if err != nil {
+		t.Fatalf("openSQLiteDB() error = %v", err)
+	}
+	// The tasks table as it was before short IDs got their own column, with
+	// two tasks claiming the same short ID
+	if _, err := db.Exec(`CREATE TABLE tasks (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT, id TEXT NOT NULL UNIQUE, status INTEGER NOT NULL,
+		created_at TEXT NOT NULL, created_day TEXT NOT NULL, scheduled_day TEXT, completed_day TEXT,
+		data TEXT NOT NULL)`); err != nil {
+		t.Fatalf("creating old tasks table error = %v", err)
+	}
+	for _, row := range []struct {
+		id      string
+		shortID int
+	}{{"old_a", 4}, {"old_b", 2}, {"old_c", 4}} {
+		if _, err := db.Exec(
+			`INSERT INTO tasks (id, status, created_at, created_day, data) VALUES (?, 0, '', '', ?)`,
+			row.id, fmt.Sprintf(`{"id": %q, "short_id": %d, "title": "Old", "status": 0}`, row.id, row.shortID),
+		); err != nil {
+			t.Fatalf("inserting old task error = %v", err)
+		}
+	}
+	db.Close()
+
+	store, err := NewSQLiteStorage(dir)
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage() error = %v", err)
+	}
+	defer store.Close()
+
+	want := map[string]int{"old_a": 4, "old_b": 2, "old_c": 5}
+	for id, shortID := range want {
+		var column int
+		if err := store.db.QueryRow(`SELECT short_id FROM tasks WHERE id = ?`, id).Scan(&column); err != nil {
+			t.Fatalf("reading short_id of %s error = %v", id, err)
+		}
+		task, err := store.GetTask(id)
+		if err != nil {
+			t.Fatalf("GetTask(%s) error = %v", id, err)
+		}
+		if column != shortID || task.ShortID != shortID {
+			t.Errorf("short ID of %s = column %d, task %d, want %d", id, column, task.ShortID, shortID)
+		}
+	}
+
+	task := newTestTask("new", time.Now(), models.TaskStatusPending)
+	if err := store.CreateTask(task); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if task.ShortID != 6 {
+		t.Errorf("short ID after migration = %d, want 6", task.ShortID)
+	}
+
+	if _, err := store.db.Exec(`UPDATE tasks SET short_id = 2 WHERE id = 'new'`); err == nil {
+		t.Errorf("duplicate short_id was accepted, want a unique constraint error")
+	}
+}
@@ -0,0 +1,807 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // pure-Go SQLite driver, no cgo required
+
+	"qomoboro/internal/models"
+)
+
+// sqliteSchema creates the tables and indexes used by SQLiteStorage.
+// Tasks, schedule and stats are stored as JSON documents so the models keep a
+// single serialization; the columns next to them only exist to be indexed.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS tasks (
+	seq            INTEGER PRIMARY KEY AUTOINCREMENT,
+	id             TEXT NOT NULL UNIQUE,
+	status         INTEGER NOT NULL,
+	created_at     TEXT NOT NULL,
+	created_day    TEXT NOT NULL,
+	scheduled_day  TEXT,
+	completed_day  TEXT,
+	short_id       INTEGER,
+	data           TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
+CREATE INDEX IF NOT EXISTS idx_tasks_created_day ON tasks(created_day);
+CREATE INDEX IF NOT EXISTS idx_tasks_scheduled_day ON tasks(scheduled_day);
+CREATE INDEX IF NOT EXISTS idx_tasks_completed_day ON tasks(completed_day);
+
+CREATE TABLE IF NOT EXISTS schedule (
+	id   INTEGER PRIMARY KEY CHECK (id = 1),
+	data TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS daily_stats (
+	date TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS counters (
+	name  TEXT PRIMARY KEY,
+	value INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS task_events (
+	seq      INTEGER PRIMARY KEY AUTOINCREMENT,
+	task_id  TEXT NOT NULL,
+	at       TEXT NOT NULL,
+	data     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_task_events_task_id ON task_events(task_id);
+CREATE INDEX IF NOT EXISTS idx_task_events_at ON task_events(at);
+`
+
+// nextShortIDCounter names the counter holding the next task short ID
+const nextShortIDCounter = "next_short_id"
+
+// SQLiteDatabaseFile is the name of the database inside the data directory
+const SQLiteDatabaseFile = "qomoboro.db"
+
+// eventTimeLayout stores event times in UTC with a fixed width, so they
+// sort and compare as text
+const eventTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// SQLiteStorage implements Storage interface using an SQLite database
+type SQLiteStorage struct {
+	dataDir   string
+	dbFile    string
+	db        *sql.DB
+	retention RetentionPolicy
+
+	eventSource string
+}
+
+// SQLiteStorageOptions tunes a SQLiteStorage instance
+type SQLiteStorageOptions struct {
+	// Retention is applied after every backup; the zero policy keeps all
+	Retention RetentionPolicy
+}
+
+// NewSQLiteStorage creates a new SQLite-backed storage instance
+func NewSQLiteStorage(dataDir string) (*SQLiteStorage, error) {
+	return NewSQLiteStorageWithOptions(dataDir, SQLiteStorageOptions{})
+}
+
+// NewSQLiteStorageWithOptions creates a new SQLite-backed storage instance
+// with the given options
+func NewSQLiteStorageWithOptions(dataDir string, opts SQLiteStorageOptions) (*SQLiteStorage, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	dbFile := filepath.Join(dataDir, SQLiteDatabaseFile)
+	db, err := openSQLiteDB(dbFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ss := &SQLiteStorage{
+		dataDir:   dataDir,
+		dbFile:    dbFile,
+		db:        db,
+		retention: opts.Retention,
+	}
+
+	if err := ss.initSchema(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	return ss, nil
+}
+
+// openSQLiteDB opens the database file with the pragmas qomoboro relies on
+func openSQLiteDB(dbFile string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", dbFile)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// SQLite serializes writers anyway; a single connection avoids SQLITE_BUSY
+	// between our own goroutines.
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+// initSchema creates tables and seeds the default schedule
+func (ss *SQLiteStorage) initSchema() error {
+	if _, err := ss.db.Exec(sqliteSchema); err != nil {
+		return err
+	}
+	if err := ss.migrateShortIDColumn(); err != nil {
+		return err
+	}
+
+	var count int
+	if err := ss.db.QueryRow(`SELECT COUNT(*) FROM schedule`).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		schedule := models.GetDefaultSchedule()
+		if err := ss.SaveSchedule(&schedule); err != nil {
+			return err
+		}
+	}
+
+	return ss.assignMissingShortIDs()
+}
+
+// migrateShortIDColumn copies the short IDs of databases created before
+// the short_id column existed out of the task documents, then makes the
+// column unique. Of tasks sharing a short ID only the oldest keeps it; the
+// others are numbered again by assignMissingShortIDs.
+func (ss *SQLiteStorage) migrateShortIDColumn() error {
+	var exists int
+	err := ss.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('tasks') WHERE name = 'short_id'`).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to inspect tasks table: %w", err)
+	}
+
+	if exists == 0 {
+		tx, err := ss.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		for _, stmt := range []string{
+			`ALTER TABLE tasks ADD COLUMN short_id INTEGER`,
+			`UPDATE tasks SET short_id = json_extract(data, '$.short_id') WHERE json_extract(data, '$.short_id') > 0`,
+			`UPDATE tasks SET short_id = NULL WHERE short_id IS NOT NULL
+			 AND seq NOT IN (SELECT MIN(seq) FROM tasks WHERE short_id IS NOT NULL GROUP BY short_id)`,
+		} {
+			if _, err := tx.Exec(stmt); err != nil {
+				return fmt.Errorf("failed to add short ID column: %w", err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to add short ID column: %w", err)
+		}
+	}
+
+	// Created here rather than in sqliteSchema, which runs before older
+	// databases have the column
+	if _, err := ss.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_short_id ON tasks(short_id)`); err != nil {
+		return fmt.Errorf("failed to index short IDs: %w", err)
+	}
+	return nil
+}
+
+// assignMissingShortIDs numbers tasks stored before short IDs existed, in
+// creation order
+func (ss *SQLiteStorage) assignMissingShortIDs() error {
+	rows, err := ss.db.Query(`SELECT data FROM tasks WHERE short_id IS NULL ORDER BY seq`)
+	if err != nil {
+		return fmt.Errorf("failed to find tasks without short IDs: %w", err)
+	}
+	tasks, err := scanTasks(rows)
+	if err != nil || len(tasks) == 0 {
+		return err
+	}
+
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, task := range tasks {
+		if err := assignShortIDTx(tx, task); err != nil {
+			return err
+		}
+		data, err := json.Marshal(task)
+		if err != nil {
+			return fmt.Errorf("failed to encode task: %w", err)
+		}
+		if _, err := tx.Exec(`UPDATE tasks SET short_id = ?, data = ? WHERE id = ?`, task.ShortID, string(data), task.ID); err != nil {
+			return fmt.Errorf("failed to save short ID: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// assignShortIDTx gives task a short ID within tx and advances the stored
+// counter past it. The task must not hold a short ID in the table yet, so
+// the highest one stored and whether the task's own is taken are all that
+// assignShortID needs; both are lookups on the short_id index.
+func assignShortIDTx(tx *sql.Tx, task *models.Task) error {
+	var next int
+	err := tx.QueryRow(`SELECT value FROM counters WHERE name = ?`, nextShortIDCounter).Scan(&next)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read short ID counter: %w", err)
+	}
+
+	var used []int
+	var highest sql.NullInt64
+	if err := tx.QueryRow(`SELECT MAX(short_id) FROM tasks`).Scan(&highest); err != nil {
+		return fmt.Errorf("failed to read short IDs: %w", err)
+	}
+	if highest.Valid {
+		used = append(used, int(highest.Int64))
+	}
+	if task.ShortID > 0 {
+		var taken bool
+		err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM tasks WHERE short_id = ?)`, task.ShortID).Scan(&taken)
+		if err != nil {
+			return fmt.Errorf("failed to read short IDs: %w", err)
+		}
+		if taken {
+			used = append(used, task.ShortID)
+		}
+	}
+
+	next = assignShortID(task, used, next)
+	if _, err := tx.Exec(
+		`INSERT INTO counters (name, value) VALUES (?, ?)
+		 ON CONFLICT(name) DO UPDATE SET value = excluded.value`,
+		nextShortIDCounter, next,
+	); err != nil {
+		return fmt.Errorf("failed to save short ID counter: %w", err)
+	}
+	return nil
+}
+
+// shortIDOf returns the task's short ID for the indexed column, or NULL when
+// it has none
+func shortIDOf(task *models.Task) interface{} {
+	if task.ShortID <= 0 {
+		return nil
+	}
+	return task.ShortID
+}
+
+// dayOf formats an optional timestamp as a day key for the indexed columns
+func dayOf(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.Format("2006-01-02")
+}
+
+// scanTasks decodes the task documents returned by a query
+func scanTasks(rows *sql.Rows) ([]*models.Task, error) {
+	defer rows.Close()
+
+	tasks := make([]*models.Task, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read task: %w", err)
+		}
+
+		var task models.Task
+		if err := json.Unmarshal([]byte(data), &task); err != nil {
+			return nil, fmt.Errorf("failed to decode task: %w", err)
+		}
+		tasks = append(tasks, &task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// CreateTask creates a new task, giving it the next short ID
+func (ss *SQLiteStorage) CreateTask(task *models.Task) error {
+	return ss.createTask(task, true)
+}
+
+// insertTask creates a task whose history was imported along with it
+func (ss *SQLiteStorage) insertTask(task *models.Task) error {
+	return ss.createTask(task, false)
+}
+
+// createTask creates a task, recording its creation in the history when
+// record is set
+func (ss *SQLiteStorage) createTask(task *models.Task, record bool) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM tasks WHERE id = ?`, task.ID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check task: %w", err)
+	}
+	if exists > 0 {
+		return fmt.Errorf("task with ID %s already exists", task.ID)
+	}
+
+	if err := assignShortIDTx(tx, task); err != nil {
+		return err
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to encode task: %w", err)
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO tasks (id, status, created_at, created_day, scheduled_day, completed_day, short_id, data)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		task.ID, int(task.Status), task.CreatedAt.UTC().Format(time.RFC3339Nano),
+		task.CreatedAt.Format("2006-01-02"), dayOf(task.ScheduledTime), dayOf(task.CompletedAt), shortIDOf(task), string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create task: %w", err)
+	}
+	if record {
+		if err := ss.recordEventTx(tx, models.EventCreate, nil, task, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to create task: %w", err)
+	}
+	return nil
+}
+
+// GetTask retrieves a task by ID
+func (ss *SQLiteStorage) GetTask(id string) (*models.Task, error) {
+	rows, err := ss.db.Query(`SELECT data FROM tasks WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task: %w", err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("task with ID %s not found", id)
+	}
+
+	return tasks[0], nil
+}
+
+// UpdateTask updates an existing task
+func (ss *SQLiteStorage) UpdateTask(task *models.Task) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	old, err := getTaskTx(tx, task.ID)
+	if err != nil {
+		return err
+	}
+
+	// Keep the caller's task as it was unless the update is committed
+	previous := task.UpdatedAt
+	task.UpdatedAt = time.Now()
+	committed := false
+	defer func() {
+		if !committed {
+			task.UpdatedAt = previous
+		}
+	}()
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to encode task: %w", err)
+	}
+
+	_, err = tx.Exec(
+		`UPDATE tasks SET status = ?, created_at = ?, created_day = ?, scheduled_day = ?, completed_day = ?, short_id = ?, data = ?
+		 WHERE id = ?`,
+		int(task.Status), task.CreatedAt.UTC().Format(time.RFC3339Nano), task.CreatedAt.Format("2006-01-02"),
+		dayOf(task.ScheduledTime), dayOf(task.CompletedAt), shortIDOf(task), string(data), task.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	if err := ss.recordEventTx(tx, models.EventUpdate, old, task, task.UpdatedAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	committed = true
+	return nil
+}
+
+// DeleteTask deletes a task by ID
+func (ss *SQLiteStorage) DeleteTask(id string) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	old, err := getTaskTx(tx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM tasks WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	if err := ss.recordEventTx(tx, models.EventDelete, old, nil, time.Now()); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	return nil
+}
+
+// getTaskTx loads a task by ID within tx
+func getTaskTx(tx *sql.Tx, id string) (*models.Task, error) {
+	rows, err := tx.Query(`SELECT data FROM tasks WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task: %w", err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("task with ID %s not found", id)
+	}
+	return tasks[0], nil
+}
+
+// ListTasks returns all tasks in creation order
+func (ss *SQLiteStorage) ListTasks() ([]*models.Task, error) {
+	rows, err := ss.db.Query(`SELECT data FROM tasks ORDER BY seq`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+	return scanTasks(rows)
+}
+
+// ListTasksByDate returns tasks created, scheduled or completed on a date
+func (ss *SQLiteStorage) ListTasksByDate(date time.Time) ([]*models.Task, error) {
+	day := date.Format("2006-01-02")
+
+	// UNION lets each branch use its own index instead of a table scan
+	rows, err := ss.db.Query(
+		`SELECT data FROM (
+			SELECT seq, created_at, data FROM tasks WHERE created_day = ?
+			UNION
+			SELECT seq, created_at, data FROM tasks WHERE scheduled_day = ?
+			UNION
+			SELECT seq, created_at, data FROM tasks WHERE completed_day = ?
+		) ORDER BY created_at, seq`,
+		day, day, day,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+	return scanTasks(rows)
+}
+
+// ListTasksByStatus returns tasks with a specific status
+func (ss *SQLiteStorage) ListTasksByStatus(status models.TaskStatus) ([]*models.Task, error) {
+	rows, err := ss.db.Query(`SELECT data FROM tasks WHERE status = ? ORDER BY seq`, int(status))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+	return scanTasks(rows)
+}
+
+// SaveSchedule saves the schedule configuration
+func (ss *SQLiteStorage) SaveSchedule(schedule *models.Schedule) error {
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule: %w", err)
+	}
+
+	_, err = ss.db.Exec(
+		`INSERT INTO schedule (id, data) VALUES (1, ?)
+		 ON CONFLICT(id) DO UPDATE SET data = excluded.data`,
+		string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save schedule: %w", err)
+	}
+
+	return nil
+}
+
+// GetSchedule returns the current schedule configuration
+func (ss *SQLiteStorage) GetSchedule() (*models.Schedule, error) {
+	var data string
+	if err := ss.db.QueryRow(`SELECT data FROM schedule WHERE id = 1`).Scan(&data); err != nil {
+		return nil, fmt.Errorf("failed to load schedule: %w", err)
+	}
+
+	var schedule models.Schedule
+	if err := json.Unmarshal([]byte(data), &schedule); err != nil {
+		return nil, fmt.Errorf("failed to load schedule: %w", err)
+	}
+
+	return &schedule, nil
+}
+
+// GetDailyStats returns statistics for a specific date
+func (ss *SQLiteStorage) GetDailyStats(date time.Time) (*models.DailyStats, error) {
+	var data string
+	err := ss.db.QueryRow(`SELECT data FROM daily_stats WHERE date = ?`, date.Format("2006-01-02")).Scan(&data)
+	if err == sql.ErrNoRows {
+		// Return empty stats if nothing was recorded for the day
+		return &models.DailyStats{
+			Date:            date,
+			HourlyBreakdown: make(map[string]models.Score),
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load daily stats: %w", err)
+	}
+
+	var stats models.DailyStats
+	if err := json.Unmarshal([]byte(data), &stats); err != nil {
+		return nil, fmt.Errorf("failed to load daily stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// SaveDailyStats saves statistics for a specific date
+func (ss *SQLiteStorage) SaveDailyStats(stats *models.DailyStats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to encode daily stats: %w", err)
+	}
+
+	_, err = ss.db.Exec(
+		`INSERT INTO daily_stats (date, data) VALUES (?, ?)
+		 ON CONFLICT(date) DO UPDATE SET data = excluded.data`,
+		stats.Date.Format("2006-01-02"), string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save daily stats: %w", err)
+	}
+
+	return nil
+}
+
+// GetWeeklyStats returns aggregated statistics for a week
+func (ss *SQLiteStorage) GetWeeklyStats(startDate time.Time) (*models.WeeklyStats, error) {
+	return buildWeeklyStats(startDate, ss.GetDailyStats)
+}
+
+// Backup creates a backup archive of the database
+func (ss *SQLiteStorage) Backup() error {
+	_, err := ss.CreateBackup(BackupLabelManual)
+	return err
+}
+
+// CreateBackup writes a consistent copy of the database into a compressed
+// archive in the backups directory
+func (ss *SQLiteStorage) CreateBackup(label string) (*BackupInfo, error) {
+	backup, err := ss.createBackup(label)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := pruneBackupArchives(ss.dataDir, ss.retention); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to prune old backups: %v\n", err)
+	}
+
+	return backup, nil
+}
+
+// createBackup runs CreateBackup without applying the retention policy
+func (ss *SQLiteStorage) createBackup(label string) (*BackupInfo, error) {
+	var count int
+	if err := ss.db.QueryRow(`SELECT COUNT(*) FROM tasks`).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+
+	// VACUUM INTO produces a self-contained snapshot even while in WAL mode
+	snapshot := filepath.Join(ss.dataDir, fmt.Sprintf(".%s.snapshot-%d", SQLiteDatabaseFile, time.Now().UnixNano()))
+	if _, err := ss.db.Exec(`VACUUM INTO ?`, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	defer os.Remove(snapshot)
+
+	data, err := os.ReadFile(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database snapshot: %w", err)
+	}
+
+	return writeBackupArchive(ss.dataDir, BackupManifest{
+		Backend:   backendSQLite,
+		Label:     label,
+		CreatedAt: time.Now(),
+		TaskCount: count,
+	}, []backupEntry{{name: SQLiteDatabaseFile, data: data}})
+}
+
+// PruneBackups deletes the backup archives that policy does not keep and
+// returns them
+func (ss *SQLiteStorage) PruneBackups(policy RetentionPolicy) ([]*BackupInfo, error) {
+	return pruneBackupArchives(ss.dataDir, policy)
+}
+
+// ListBackups returns all backup archives, oldest first
+func (ss *SQLiteStorage) ListBackups() ([]*BackupInfo, error) {
+	return listBackupArchives(ss.dataDir)
+}
+
+// VerifyBackup checks a backup archive against its manifest
+func (ss *SQLiteStorage) VerifyBackup(id string) (*BackupInfo, error) {
+	backup, _, err := verifyBackup(ss.dataDir, id)
+	return backup, err
+}
+
+// RestoreBackup replaces the database with the one in a backup archive.
+// The current database is archived first and that safety backup is returned.
+func (ss *SQLiteStorage) RestoreBackup(id string) (*BackupInfo, error) {
+	backup, files, err := verifyBackup(ss.dataDir, id)
+	if err != nil {
+		return nil, err
+	}
+	if backup.Manifest.Backend != backendSQLite {
+		return nil, fmt.Errorf("backup %s was taken from the %s backend", backup.ID, backup.Manifest.Backend)
+	}
+	data, ok := files[SQLiteDatabaseFile]
+	if !ok {
+		return nil, fmt.Errorf("backup %s has no %s", backup.ID, SQLiteDatabaseFile)
+	}
+
+	safety, err := ss.createBackup(BackupLabelPreRestore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pre-restore backup: %w", err)
+	}
+
+	if err := ss.db.Close(); err != nil {
+		return safety, fmt.Errorf("failed to close database: %w", err)
+	}
+
+	// The restored file replaces the database and any write-ahead log
+	restoreErr := writeFileAtomic(ss.dbFile, data, false)
+	if restoreErr == nil {
+		os.Remove(ss.dbFile + "-wal")
+		os.Remove(ss.dbFile + "-shm")
+	}
+
+	db, err := openSQLiteDB(ss.dbFile)
+	if err != nil {
+		return safety, err
+	}
+	ss.db = db
+
+	if restoreErr != nil {
+		return safety, fmt.Errorf("failed to restore database: %w", restoreErr)
+	}
+	if err := ss.initSchema(); err != nil {
+		return safety, fmt.Errorf("failed to initialize restored database: %w", err)
+	}
+
+	return safety, nil
+}
+
+// SetEventSource sets what the events of later changes are attributed to
+func (ss *SQLiteStorage) SetEventSource(source string) {
+	ss.eventSource = source
+}
+
+// recordEventTx stores the event for a change made within tx, so the two
+// are committed together
+func (ss *SQLiteStorage) recordEventTx(tx *sql.Tx, action models.EventAction, old, new *models.Task, at time.Time) error {
+	event, err := models.NewTaskEvent(action, old, new, at, ss.eventSource)
+	if err != nil {
+		return fmt.Errorf("failed to record task history: %w", err)
+	}
+	if event == nil {
+		return nil
+	}
+
+	return insertEventTx(tx, event)
+}
+
+// insertEventTx adds event to the task_events table
+func insertEventTx(tx *sql.Tx, event *models.TaskEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode task event: %w", err)
+	}
+	_, err = tx.Exec(`INSERT INTO task_events (task_id, at, data) VALUES (?, ?, ?)`,
+		event.TaskID, event.At.UTC().Format(eventTimeLayout), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to record task history: %w", err)
+	}
+	return nil
+}
+
+// appendEvents adds events recorded elsewhere, in a single transaction
+func (ss *SQLiteStorage) appendEvents(events []*models.TaskEvent) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, event := range events {
+		if err := insertEventTx(tx, event); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListEvents returns the task events filter selects, oldest first
+func (ss *SQLiteStorage) ListEvents(filter EventFilter) ([]*models.TaskEvent, error) {
+	query := `SELECT data FROM task_events WHERE 1 = 1`
+	var args []interface{}
+	if filter.TaskID != "" {
+		query += ` AND task_id = ?`
+		args = append(args, filter.TaskID)
+	}
+	if !filter.From.IsZero() {
+		query += ` AND at >= ?`
+		args = append(args, filter.From.UTC().Format(eventTimeLayout))
+	}
+	if !filter.To.IsZero() {
+		query += ` AND at < ?`
+		args = append(args, filter.To.UTC().Format(eventTimeLayout))
+	}
+
+	rows, err := ss.db.Query(query+` ORDER BY seq`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task history: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.TaskEvent
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to load task history: %w", err)
+		}
+		var event models.TaskEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, fmt.Errorf("failed to decode task event: %w", err)
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+// Close closes the database connection
+func (ss *SQLiteStorage) Close() error {
+	return ss.db.Close()
+}
+
+// GetDataDir returns the data directory path
+func (ss *SQLiteStorage) GetDataDir() string {
+	return ss.dataDir
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+func newTestTask(id string, created time.Time, status models.TaskStatus) *models.Task {
+	return &models.Task{
+		ID:        id,
+		Title:     "Task " + id,
+		Score:     models.Score{Work: 3, Play: 1, Learn: 2},
+		Status:    status,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+}
+
+func TestSQLiteStorage_TaskCRUD(t *testing.T) {
+	store, err := NewSQLiteStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage() error = %v", err)
+	}
+	defer store.Close()
+
+	task := newTestTask("a", time.Now(), models.TaskStatusPending)
+	if err := store.CreateTask(task); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if err := store.CreateTask(task); err == nil {
+		t.Errorf("CreateTask() duplicate ID error = nil, want error")
+	}
+
+	got, err := store.GetTask("a")
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if got.Title != task.Title || got.Score != task.Score {
+		t.Errorf("GetTask() = %+v, want %+v", got, task)
+	}
+
+	got.Complete()
+	if err := store.UpdateTask(got); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+
+	completed, err := store.ListTasksByStatus(models.TaskStatusCompleted)
+	if err != nil {
+		t.Fatalf("ListTasksByStatus() error = %v", err)
+	}
+	if len(completed) != 1 || completed[0].ID != "a" {
+		t.Errorf("ListTasksByStatus(completed) = %v, want task a", completed)
+	}
+
+	if err := store.DeleteTask("a"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if _, err := store.GetTask("a"); err == nil {
+		t.Errorf("GetTask() after delete error = nil, want error")
+	}
+	if err := store.DeleteTask("a"); err == nil {
+		t.Errorf("DeleteTask() missing task error = nil, want error")
+	}
+	if err := store.UpdateTask(got); err == nil {
+		t.Errorf("UpdateTask() missing task error = nil, want error")
+	}
+}
+
+func TestSQLiteStorage_ListTasksByDate(t *testing.T) {
+	store, err := NewSQLiteStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage() error = %v", err)
+	}
+	defer store.Close()
+
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+
+	created := newTestTask("created", day.Add(time.Hour), models.TaskStatusPending)
+	scheduled := newTestTask("scheduled", day.AddDate(0, 0, -2), models.TaskStatusPending)
+	scheduledAt := day.Add(2 * time.Hour)
+	scheduled.ScheduledTime = &scheduledAt
+	completed := newTestTask("completed", day.AddDate(0, 0, -1), models.TaskStatusCompleted)
+	completedAt := day.Add(3 * time.Hour)
+	completed.CompletedAt = &completedAt
+	other := newTestTask("other", day.AddDate(0, 0, 1), models.TaskStatusPending)
+
+	for _, task := range []*models.Task{created, scheduled, completed, other} {
+		if err := store.CreateTask(task); err != nil {
+			t.Fatalf("CreateTask(%s) error = %v", task.ID, err)
+		}
+	}
+
+	tasks, err := store.ListTasksByDate(day)
+	if err != nil {
+		t.Fatalf("ListTasksByDate() error = %v", err)
+	}
+
+	want := []string{"scheduled", "completed", "created"}
+	if len(tasks) != len(want) {
+		t.Fatalf("ListTasksByDate() returned %d tasks, want %d", len(tasks), len(want))
+	}
+	for i, id := range want {
+		if tasks[i].ID != id {
+			t.Errorf("ListTasksByDate()[%d] = %s, want %s", i, tasks[i].ID, id)
+		}
+	}
+}
+
+func TestImportFileStorage(t *testing.T) {
+	srcDir := t.TempDir()
+	src, err := NewFileStorage(srcDir)
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+	src.SetEventSource("cli add")
+	for _, id := range []string{"a", "b"} {
+		if err := src.CreateTask(newTestTask(id, time.Now(), models.TaskStatusPending)); err != nil {
+			t.Fatalf("CreateTask() error = %v", err)
+		}
+	}
+	task, _ := src.GetTask("a")
+	task.Complete()
+	if err := src.UpdateTask(task); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+	// A task from before the history existed
+	if err := src.insertTask(newTestTask("c", time.Now(), models.TaskStatusPending)); err != nil {
+		t.Fatalf("insertTask() error = %v", err)
+	}
+	stats := &models.DailyStats{Date: time.Now(), TotalTasks: 2}
+	if err := src.SaveDailyStats(stats); err != nil {
+		t.Fatalf("SaveDailyStats() error = %v", err)
+	}
+
+	dst, err := NewSQLiteStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage() error = %v", err)
+	}
+	defer dst.Close()
+	dst.SetEventSource("cli migrate")
+
+	result, err := ImportFileStorage(srcDir, dst)
+	if err != nil {
+		t.Fatalf("ImportFileStorage() error = %v", err)
+	}
+	if result.Tasks != 3 || result.Stats != 1 || result.Events != 3 {
+		t.Errorf("ImportFileStorage() = %+v, want 3 tasks, 1 stats and 3 events", result)
+	}
+
+	// Importing again must not duplicate tasks
+	result, err = ImportFileStorage(srcDir, dst)
+	if err != nil {
+		t.Fatalf("ImportFileStorage() second run error = %v", err)
+	}
+	if result.Tasks != 0 || result.SkippedTasks != 3 || result.Events != 0 {
+		t.Errorf("ImportFileStorage() second run = %+v, want 3 skipped and no events", result)
+	}
+
+	// Tasks keep their own history and are created only once; a task
+	// without history gets its creation recorded by the import
+	for _, tt := range []struct {
+		id      string
+		want    []models.EventAction
+		creator string
+	}{
+		{id: "a", want: []models.EventAction{models.EventCreate, models.EventUpdate}, creator: "cli add"},
+		{id: "b", want: []models.EventAction{models.EventCreate}, creator: "cli add"},
+		{id: "c", want: []models.EventAction{models.EventCreate}, creator: "cli migrate"},
+	} {
+		events, err := dst.ListEvents(EventFilter{TaskID: tt.id})
+		if err != nil {
+			t.Fatalf("ListEvents() error = %v", err)
+		}
+		var got []models.EventAction
+		for _, event := range events {
+			got = append(got, event.Action)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("events of task %s after import = %v, want %v", tt.id, got, tt.want)
+			continue
+		}
+		if events[0].Source != tt.creator {
+			t.Errorf("task %s created by %q, want %q", tt.id, events[0].Source, tt.creator)
+		}
+	}
+
+	got, err := dst.GetDailyStats(time.Now())
+	if err != nil {
+		t.Fatalf("GetDailyStats() error = %v", err)
+	}
+	if got.TotalTasks != 2 {
+		t.Errorf("GetDailyStats().TotalTasks = %d, want 2", got.TotalTasks)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -33,22 +34,74 @@ type Storage interface {
 	GetWeeklyStats(startDate time.Time) (*models.WeeklyStats, error)
 	SaveDailyStats(stats *models.DailyStats) error
 
+	// Backup operations
+	CreateBackup(label string) (*BackupInfo, error)
+	ListBackups() ([]*BackupInfo, error)
+	VerifyBackup(id string) (*BackupInfo, error)
+	RestoreBackup(id string) (*BackupInfo, error)
+	PruneBackups(policy RetentionPolicy) ([]*BackupInfo, error)
+
+	// History operations. Creating, updating and deleting a task records
+	// an event attributed to the current event source.
+	ListEvents(filter EventFilter) ([]*models.TaskEvent, error)
+	SetEventSource(source string)
+
 	// Utility operations
 	Close() error
 	Backup() error
 }
 
+// Backend names recorded in backup manifests
+const (
+	backendFile   = "file"
+	backendSQLite = "sqlite"
+)
+
 // FileStorage implements Storage interface using file-based persistence
 type FileStorage struct {
 	dataDir   string
 	tasksFile string
 	schedFile string
 	statsDir  string
+	histFile  string
 	mu        sync.RWMutex
+	lock      *fileLock
+	retention RetentionPolicy
+
+	autoBackup     bool
+	lastAutoBackup string // Day of the last automatic backup seen, as 2006-01-02
+
+	eventSource string
+}
+
+// FileStorageOptions tunes a FileStorage instance
+type FileStorageOptions struct {
+	// LockTimeout is how long a write waits for another process holding
+	// the data directory lock before failing with ErrLockTimeout
+	LockTimeout time.Duration
+
+	// Retention is applied after every backup; the zero policy keeps all
+	Retention RetentionPolicy
+
+	// AutoBackup takes a backup before the first write of each day
+	AutoBackup bool
+}
+
+// DefaultFileStorageOptions returns the options used by NewFileStorage
+func DefaultFileStorageOptions() FileStorageOptions {
+	return FileStorageOptions{
+		LockTimeout: DefaultLockTimeout,
+	}
 }
 
 // NewFileStorage creates a new file-based storage instance
 func NewFileStorage(dataDir string) (*FileStorage, error) {
+	return NewFileStorageWithOptions(dataDir, DefaultFileStorageOptions())
+}
+
+// NewFileStorageWithOptions creates a new file-based storage instance with
+// the given options
+func NewFileStorageWithOptions(dataDir string, opts FileStorageOptions) (*FileStorage, error) {
 	// Create data directory if it doesn't exist
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
@@ -65,6 +118,18 @@ func NewFileStorage(dataDir string) (*FileStorage, error) {
 		tasksFile: filepath.Join(dataDir, "tasks.json"),
 		schedFile: filepath.Join(dataDir, "schedule.json"),
 		statsDir:  statsDir,
+		histFile:  filepath.Join(dataDir, HistoryFileName),
+		lock: &fileLock{
+			path:    filepath.Join(dataDir, LockFileName),
+			timeout: opts.LockTimeout,
+		},
+		retention:  opts.Retention,
+		autoBackup: opts.AutoBackup,
+	}
+
+	// Upgrade data written by older versions
+	if err := fs.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate storage files: %w", err)
 	}
 
 	// Initialize files if they don't exist
@@ -79,8 +144,8 @@ func NewFileStorage(dataDir string) (*FileStorage, error) {
 func (fs *FileStorage) initFiles() error {
 	// Initialize tasks file
 	if _, err := os.Stat(fs.tasksFile); os.IsNotExist(err) {
-		tasks := make([]*models.Task, 0)
-		if err := fs.writeJSON(fs.tasksFile, tasks); err != nil {
+		doc := &tasksDocument{Tasks: make([]*models.Task, 0)}
+		if err := fs.saveTasks(doc); err != nil {
 			return fmt.Errorf("failed to initialize tasks file: %w", err)
 		}
 	}
@@ -88,7 +153,7 @@ func (fs *FileStorage) initFiles() error {
 	// Initialize schedule file with default schedule
 	if _, err := os.Stat(fs.schedFile); os.IsNotExist(err) {
 		schedule := models.GetDefaultSchedule()
-		if err := fs.writeJSON(fs.schedFile, schedule); err != nil {
+		if err := fs.saveSchedule(&schedule); err != nil {
 			return fmt.Errorf("failed to initialize schedule file: %w", err)
 		}
 	}
@@ -96,17 +161,16 @@ func (fs *FileStorage) initFiles() error {
 	return nil
 }
 
-// writeJSON writes data to a JSON file
+// writeJSON atomically writes data to a JSON file. Tasks and schedule keep
+// their previous version as a .bak file for recovery.
 func (fs *FileStorage) writeJSON(filename string, data interface{}) error {
-	file, err := os.Create(filename)
+	encoded, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(data)
+	keepBackup := filename == fs.tasksFile || filename == fs.schedFile
+	return writeFileAtomic(filename, append(encoded, '\n'), keepBackup)
 }
 
 // readJSON reads data from a JSON file
@@ -121,39 +185,108 @@ func (fs *FileStorage) readJSON(filename string, data interface{}) error {
 	return decoder.Decode(data)
 }
 
+// readJSONWithBackup reads data from a JSON file, falling back to its .bak
+// copy with a warning when the primary exists but cannot be decoded
+func (fs *FileStorage) readJSONWithBackup(filename string, data interface{}) error {
+	err := fs.readJSON(filename, data)
+	if err == nil || os.IsNotExist(err) {
+		return err
+	}
+
+	backup := filename + backupSuffix
+	if backupErr := fs.readJSON(backup, data); backupErr != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: %s is unreadable (%v); using backup %s\n",
+		filepath.Base(filename), err, filepath.Base(backup))
+	return nil
+}
+
 // loadTasks loads all tasks from storage
 func (fs *FileStorage) loadTasks() ([]*models.Task, error) {
-	var tasks []*models.Task
-	if err := fs.readJSON(fs.tasksFile, &tasks); err != nil {
+	doc, err := fs.loadTasksDocument()
+	if err != nil {
+		return nil, err
+	}
+	return doc.Tasks, nil
+}
+
+// loadTasksDocument loads the tasks file, including the short ID counter
+func (fs *FileStorage) loadTasksDocument() (*tasksDocument, error) {
+	var doc tasksDocument
+	if err := fs.readJSONWithBackup(fs.tasksFile, &doc); err != nil {
 		return nil, fmt.Errorf("failed to load tasks: %w", err)
 	}
-	return tasks, nil
+	if err := checkSchemaVersion(fs.tasksFile, doc.Version); err != nil {
+		return nil, err
+	}
+	if doc.Tasks == nil {
+		doc.Tasks = make([]*models.Task, 0)
+	}
+	return &doc, nil
 }
 
-// saveTasks saves all tasks to storage
-func (fs *FileStorage) saveTasks(tasks []*models.Task) error {
-	return fs.writeJSON(fs.tasksFile, tasks)
+// saveTasks saves the tasks file at the current schema version
+func (fs *FileStorage) saveTasks(doc *tasksDocument) error {
+	doc.Version = CurrentSchemaVersion
+	return fs.writeJSON(fs.tasksFile, doc)
+}
+
+// saveSchedule writes the schedule file
+func (fs *FileStorage) saveSchedule(schedule *models.Schedule) error {
+	return fs.writeJSON(fs.schedFile, scheduleDocument{
+		Version:  CurrentSchemaVersion,
+		Schedule: schedule,
+	})
 }
 
 // CreateTask creates a new task
 func (fs *FileStorage) CreateTask(task *models.Task) error {
+	return fs.createTask(task, true)
+}
+
+// insertTask creates a task whose history was imported along with it
+func (fs *FileStorage) insertTask(task *models.Task) error {
+	return fs.createTask(task, false)
+}
+
+// createTask creates a task, recording its creation in the history when
+// record is set
+func (fs *FileStorage) createTask(task *models.Task, record bool) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
-	tasks, err := fs.loadTasks()
+	unlock, err := fs.lock.acquire()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	fs.autoBackupLocked()
+
+	doc, err := fs.loadTasksDocument()
 	if err != nil {
 		return err
 	}
 
 	// Check for duplicate ID
-	for _, existing := range tasks {
+	for _, existing := range doc.Tasks {
 		if existing.ID == task.ID {
 			return fmt.Errorf("task with ID %s already exists", task.ID)
 		}
 	}
 
-	tasks = append(tasks, task)
-	return fs.saveTasks(tasks)
+	doc.NextShortID = assignShortID(task, shortIDs(doc.Tasks), doc.NextShortID)
+	doc.Tasks = append(doc.Tasks, task)
+	if err := fs.saveTasks(doc); err != nil {
+		return err
+	}
+
+	if record {
+		fs.recordEventLocked(models.EventCreate, nil, task, time.Now())
+	}
+	return nil
 }
 
 // GetTask retrieves a task by ID
@@ -180,16 +313,29 @@ func (fs *FileStorage) UpdateTask(task *models.Task) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
-	tasks, err := fs.loadTasks()
+	unlock, err := fs.lock.acquire()
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	for i, existing := range tasks {
+	fs.autoBackupLocked()
+
+	doc, err := fs.loadTasksDocument()
+	if err != nil {
+		return err
+	}
+
+	for i, existing := range doc.Tasks {
 		if existing.ID == task.ID {
 			task.UpdatedAt = time.Now()
-			tasks[i] = task
-			return fs.saveTasks(tasks)
+			doc.Tasks[i] = task
+			if err := fs.saveTasks(doc); err != nil {
+				return err
+			}
+
+			fs.recordEventLocked(models.EventUpdate, existing, task, task.UpdatedAt)
+			return nil
 		}
 	}
 
@@ -201,15 +347,28 @@ func (fs *FileStorage) DeleteTask(id string) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
-	tasks, err := fs.loadTasks()
+	unlock, err := fs.lock.acquire()
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	for i, task := range tasks {
+	fs.autoBackupLocked()
+
+	doc, err := fs.loadTasksDocument()
+	if err != nil {
+		return err
+	}
+
+	for i, task := range doc.Tasks {
 		if task.ID == id {
-			tasks = append(tasks[:i], tasks[i+1:]...)
-			return fs.saveTasks(tasks)
+			doc.Tasks = append(doc.Tasks[:i], doc.Tasks[i+1:]...)
+			if err := fs.saveTasks(doc); err != nil {
+				return err
+			}
+
+			fs.recordEventLocked(models.EventDelete, task, nil, time.Now())
+			return nil
 		}
 	}
 
@@ -290,7 +449,15 @@ func (fs *FileStorage) SaveSchedule(schedule *models.Schedule) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
-	return fs.writeJSON(fs.schedFile, schedule)
+	unlock, err := fs.lock.acquire()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	fs.autoBackupLocked()
+
+	return fs.saveSchedule(schedule)
 }
 
 // GetSchedule returns the current schedule configuration
@@ -298,12 +465,18 @@ func (fs *FileStorage) GetSchedule() (*models.Schedule, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
-	var schedule models.Schedule
-	if err := fs.readJSON(fs.schedFile, &schedule); err != nil {
+	var doc scheduleDocument
+	if err := fs.readJSONWithBackup(fs.schedFile, &doc); err != nil {
 		return nil, fmt.Errorf("failed to load schedule: %w", err)
 	}
+	if err := checkSchemaVersion(fs.schedFile, doc.Version); err != nil {
+		return nil, err
+	}
+	if doc.Schedule == nil {
+		return nil, fmt.Errorf("failed to load schedule: %s has no schedule", filepath.Base(fs.schedFile))
+	}
 
-	return &schedule, nil
+	return doc.Schedule, nil
 }
 
 // GetDailyStats returns statistics for a specific date
@@ -342,6 +515,11 @@ func (fs *FileStorage) GetWeeklyStats(startDate time.Time) (*models.WeeklyStats,
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
+	return buildWeeklyStats(startDate, fs.GetDailyStats)
+}
+
+// buildWeeklyStats aggregates seven days of daily stats starting at startDate
+func buildWeeklyStats(startDate time.Time, dailyStatsFor func(time.Time) (*models.DailyStats, error)) (*models.WeeklyStats, error) {
 	// Calculate week boundaries
 	endDate := startDate.AddDate(0, 0, 6)
 
@@ -351,7 +529,7 @@ func (fs *FileStorage) GetWeeklyStats(startDate time.Time) (*models.WeeklyStats,
 
 	// Load daily stats for each day of the week
 	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
-		dayStats, err := fs.GetDailyStats(d)
+		dayStats, err := dailyStatsFor(d)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load stats for %s: %w", d.Format("2006-01-02"), err)
 		}
@@ -380,88 +558,282 @@ func (fs *FileStorage) GetWeeklyStats(startDate time.Time) (*models.WeeklyStats,
 	}, nil
 }
 
-// Backup creates a backup of all data
+// Backup creates a backup archive of all data
 func (fs *FileStorage) Backup() error {
+	_, err := fs.CreateBackup(BackupLabelManual)
+	return err
+}
+
+// CreateBackup writes tasks, schedule and stats into a compressed archive
+// in the backups directory
+func (fs *FileStorage) CreateBackup(label string) (*BackupInfo, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
-	backupDir := filepath.Join(fs.dataDir, "backups")
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
-		return fmt.Errorf("failed to create backup directory: %w", err)
+	unlock, err := fs.lock.acquire()
+	if err != nil {
+		return nil, err
 	}
+	defer unlock()
 
-	timestamp := time.Now().Format("20060102_150405")
+	backup, err := fs.createBackupLocked(label)
+	if err != nil {
+		return nil, err
+	}
 
-	// For now, just copy the JSON files
-	// In a more sophisticated implementation, we'd create a proper archive
-	backupDataDir := filepath.Join(backupDir, fmt.Sprintf("data_%s", timestamp))
-	if err := os.MkdirAll(backupDataDir, 0755); err != nil {
-		return fmt.Errorf("failed to create backup data directory: %w", err)
+	if _, err := pruneBackupArchives(fs.dataDir, fs.retention); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to prune old backups: %v\n", err)
 	}
 
-	// Copy tasks file
-	if err := fs.copyFile(fs.tasksFile, filepath.Join(backupDataDir, "tasks.json")); err != nil {
-		return fmt.Errorf("failed to backup tasks: %w", err)
+	return backup, nil
+}
+
+// autoBackupLocked takes the day's automatic backup if it has not been
+// taken yet. It runs before a write with both locks held, so the backup
+// holds the data as it was before the day's first change. Failures only
+// warn, as a missed backup should not block the write.
+func (fs *FileStorage) autoBackupLocked() {
+	if !fs.autoBackup {
+		return
 	}
 
-	// Copy schedule file
-	if err := fs.copyFile(fs.schedFile, filepath.Join(backupDataDir, "schedule.json")); err != nil {
-		return fmt.Errorf("failed to backup schedule: %w", err)
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	if fs.lastAutoBackup == today {
+		return
 	}
 
-	// Copy stats directory
-	if err := fs.copyDir(fs.statsDir, filepath.Join(backupDataDir, "stats")); err != nil {
-		return fmt.Errorf("failed to backup stats: %w", err)
+	// Another process may already have taken today's backup
+	done, err := hasAutoBackupOn(fs.dataDir, now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: automatic backup skipped: %v\n", err)
+		return
+	}
+	if !done {
+		if _, err := fs.createBackupLocked(BackupLabelAuto); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: automatic backup failed: %v\n", err)
+			return
+		}
+		if err := markAutoBackupOn(fs.dataDir, now); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if _, err := pruneBackupArchives(fs.dataDir, fs.retention); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to prune old backups: %v\n", err)
+		}
 	}
 
-	return nil
+	fs.lastAutoBackup = today
 }
 
-// copyFile copies a file from src to dst
-func (fs *FileStorage) copyFile(src, dst string) error {
-	srcFile, err := os.Open(src)
+// createBackupLocked runs CreateBackup with both locks already held
+func (fs *FileStorage) createBackupLocked(label string) (*BackupInfo, error) {
+	tasks, err := fs.loadTasks()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to backup tasks: %w", err)
 	}
-	defer srcFile.Close()
 
-	dstFile, err := os.Create(dst)
+	var entries []backupEntry
+	for _, file := range []string{fs.tasksFile, fs.schedFile} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to backup %s: %w", filepath.Base(file), err)
+		}
+		entries = append(entries, backupEntry{name: filepath.Base(file), data: data})
+	}
+
+	// The history is only there once a task has been changed
+	history, err := os.ReadFile(fs.histFile)
+	if err == nil {
+		entries = append(entries, backupEntry{name: HistoryFileName, data: history})
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to backup %s: %w", HistoryFileName, err)
+	}
+
+	statsFiles, err := os.ReadDir(fs.statsDir)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to backup stats: %w", err)
+	}
+	for _, entry := range statsFiles {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(fs.statsDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to backup stats: %w", err)
+		}
+		entries = append(entries, backupEntry{name: "stats/" + entry.Name(), data: data})
 	}
-	defer dstFile.Close()
 
-	_, err = io.Copy(dstFile, srcFile)
-	return err
+	return writeBackupArchive(fs.dataDir, BackupManifest{
+		Backend:   backendFile,
+		Label:     label,
+		CreatedAt: time.Now(),
+		TaskCount: len(tasks),
+	}, entries)
 }
 
-// copyDir copies a directory from src to dst
-func (fs *FileStorage) copyDir(src, dst string) error {
-	if err := os.MkdirAll(dst, 0755); err != nil {
-		return err
+// PruneBackups deletes the backup archives that policy does not keep and
+// returns them
+func (fs *FileStorage) PruneBackups(policy RetentionPolicy) ([]*BackupInfo, error) {
+	unlock, err := fs.lock.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return pruneBackupArchives(fs.dataDir, policy)
+}
+
+// ListBackups returns all backup archives, oldest first
+func (fs *FileStorage) ListBackups() ([]*BackupInfo, error) {
+	return listBackupArchives(fs.dataDir)
+}
+
+// VerifyBackup checks a backup archive against its manifest
+func (fs *FileStorage) VerifyBackup(id string) (*BackupInfo, error) {
+	backup, _, err := verifyBackup(fs.dataDir, id)
+	return backup, err
+}
+
+// RestoreBackup replaces all data with the contents of a backup archive.
+// The current data is archived first and that safety backup is returned.
+func (fs *FileStorage) RestoreBackup(id string) (*BackupInfo, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	unlock, err := fs.lock.acquire()
+	if err != nil {
+		return nil, err
 	}
+	defer unlock()
 
-	entries, err := os.ReadDir(src)
+	backup, files, err := verifyBackup(fs.dataDir, id)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if backup.Manifest.Backend != backendFile {
+		return nil, fmt.Errorf("backup %s was taken from the %s backend", backup.ID, backup.Manifest.Backend)
+	}
+	if err := checkSchemaVersion("backup "+backup.ID, backup.Manifest.SchemaVersion); err != nil {
+		return nil, err
 	}
 
-	for _, entry := range entries {
-		srcPath := filepath.Join(src, entry.Name())
-		dstPath := filepath.Join(dst, entry.Name())
+	safety, err := fs.createBackupLocked(BackupLabelPreRestore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pre-restore backup: %w", err)
+	}
 
-		if entry.IsDir() {
-			if err := fs.copyDir(srcPath, dstPath); err != nil {
-				return err
-			}
-		} else {
-			if err := fs.copyFile(srcPath, dstPath); err != nil {
-				return err
+	for _, file := range []string{fs.tasksFile, fs.schedFile} {
+		data, ok := files[filepath.Base(file)]
+		if !ok {
+			return safety, fmt.Errorf("backup %s has no %s", backup.ID, filepath.Base(file))
+		}
+		if err := writeFileAtomic(file, data, true); err != nil {
+			return safety, fmt.Errorf("failed to restore %s: %w", filepath.Base(file), err)
+		}
+	}
+
+	// The history goes back to the backup too; the safety backup keeps
+	// the events since. Archives from before the history have none.
+	if history, ok := files[HistoryFileName]; ok {
+		err = writeFileAtomic(fs.histFile, history, false)
+	} else if err = os.Remove(fs.histFile); os.IsNotExist(err) {
+		err = nil
+	}
+	if err != nil {
+		return safety, fmt.Errorf("failed to restore %s: %w", HistoryFileName, err)
+	}
+
+	// Replace the stats snapshots wholesale so no stale days remain
+	statsFiles, err := os.ReadDir(fs.statsDir)
+	if err != nil {
+		return safety, fmt.Errorf("failed to restore stats: %w", err)
+	}
+	for _, entry := range statsFiles {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			if err := os.Remove(filepath.Join(fs.statsDir, entry.Name())); err != nil {
+				return safety, fmt.Errorf("failed to restore stats: %w", err)
 			}
 		}
 	}
+	for name, data := range files {
+		if !strings.HasPrefix(name, "stats/") {
+			continue
+		}
+		if err := writeFileAtomic(filepath.Join(fs.statsDir, filepath.Base(name)), data, false); err != nil {
+			return safety, fmt.Errorf("failed to restore stats: %w", err)
+		}
+	}
 
-	return nil
+	// Archives from older versions are upgraded like any other old data
+	if err := fs.migrateLocked(); err != nil {
+		return safety, err
+	}
+
+	return safety, nil
+}
+
+// verifyBackup resolves a backup ID and verifies the archive, including that
+// a file backend archive holds the number of tasks its manifest claims
+func verifyBackup(dataDir, id string) (*BackupInfo, map[string][]byte, error) {
+	backup, err := findBackupArchive(dataDir, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifest, files, err := verifyBackupArchive(backup.Path)
+	if err != nil {
+		return backup, nil, fmt.Errorf("backup %s is invalid: %w", backup.ID, err)
+	}
+	backup.Manifest = manifest
+
+	if manifest.Backend == backendFile {
+		count, err := countArchivedTasks(files["tasks.json"])
+		if err != nil {
+			return backup, nil, fmt.Errorf("backup %s is invalid: %w", backup.ID, err)
+		}
+		if count != manifest.TaskCount {
+			return backup, nil, fmt.Errorf("backup %s is invalid: holds %d tasks, manifest says %d",
+				backup.ID, count, manifest.TaskCount)
+		}
+	}
+
+	return backup, files, nil
+}
+
+// countArchivedTasks counts the tasks in an archived tasks.json of any
+// schema version
+func countArchivedTasks(data []byte) (int, error) {
+	var tasks []json.RawMessage
+	if err := json.Unmarshal(data, &tasks); err == nil {
+		return len(tasks), nil
+	}
+
+	var doc struct {
+		Tasks []json.RawMessage `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return 0, fmt.Errorf("tasks.json is unreadable: %w", err)
+	}
+	return len(doc.Tasks), nil
+}
+
+// copyFile copies a file from src to dst
+func (fs *FileStorage) copyFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+
+	_, err = io.Copy(dstFile, srcFile)
+	return err
 }
 
 // Close closes the storage (no-op for file storage)
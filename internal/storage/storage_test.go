@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+func TestFileStorage_WriteKeepsBackup(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+
+	if err := store.CreateTask(newTestTask("a", time.Now(), models.TaskStatusPending)); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if err := store.CreateTask(newTestTask("b", time.Now(), models.TaskStatusPending)); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	var backup tasksDocument
+	if err := store.readJSON(filepath.Join(dir, "tasks.json.bak"), &backup); err != nil {
+		t.Fatalf("reading tasks.json.bak error = %v", err)
+	}
+	if len(backup.Tasks) != 1 || backup.Tasks[0].ID != "a" {
+		t.Errorf("tasks.json.bak = %v, want previous version with task a", backup)
+	}
+
+	// No temp files may be left behind
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp-*"))
+	if len(matches) != 0 {
+		t.Errorf("temp files left behind: %v", matches)
+	}
+}
+
+func TestFileStorage_LoadFallsBackToBackup(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+
+	for _, id := range []string{"a", "b"} {
+		if err := store.CreateTask(newTestTask(id, time.Now(), models.TaskStatusPending)); err != nil {
+			t.Fatalf("CreateTask() error = %v", err)
+		}
+	}
+
+	// Simulate a write that was cut off half way
+	if err := os.WriteFile(filepath.Join(dir, "tasks.json"), []byte(`{"version": 2, "tasks": [{"id": "a", "ti`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := store.ListTasks()
+	if err != nil {
+		t.Fatalf("ListTasks() error = %v, want fallback to backup", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "a" {
+		t.Errorf("ListTasks() = %v, want backup contents", tasks)
+	}
+
+	// Saving over the corrupt primary must not replace the good backup
+	if err := store.CreateTask(newTestTask("c", time.Now(), models.TaskStatusPending)); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	var backup tasksDocument
+	if err := store.readJSON(filepath.Join(dir, "tasks.json.bak"), &backup); err != nil {
+		t.Fatalf("reading tasks.json.bak error = %v", err)
+	}
+	if len(backup.Tasks) != 1 || backup.Tasks[0].ID != "a" {
+		t.Errorf("tasks.json.bak = %v, want it untouched", backup)
+	}
+}
+
+func TestFileStorage_LockTimeout(t *testing.T) {
+	dir := t.TempDir()
+	opts := DefaultFileStorageOptions()
+	opts.LockTimeout = 50 * time.Millisecond
+	store, err := NewFileStorageWithOptions(dir, opts)
+	if err != nil {
+		t.Fatalf("NewFileStorageWithOptions() error = %v", err)
+	}
+
+	// Another process holding the lock is simulated by a second open file
+	// description, which flock treats as a separate owner
+	other := &fileLock{path: filepath.Join(dir, LockFileName), timeout: time.Second}
+	release, err := other.acquire()
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	err = store.CreateTask(newTestTask("a", time.Now(), models.TaskStatusPending))
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("CreateTask() while locked error = %v, want ErrLockTimeout", err)
+	}
+
+	release()
+	if err := store.CreateTask(newTestTask("a", time.Now(), models.TaskStatusPending)); err != nil {
+		t.Errorf("CreateTask() after release error = %v", err)
+	}
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"qomoboro/internal/models"
+	"qomoboro/internal/stats"
 	"qomoboro/internal/storage"
+	"qomoboro/pomodoro"
 )
 
 // ViewMode represents different application views
@@ -24,6 +26,7 @@ const (
 	ViewModeCreateTask
 	ViewModeTaskDetail
 	ViewModeSettings
+	ViewModeTimer
 )
 
 // Colors and styles
@@ -52,6 +55,7 @@ type Styles struct {
 	Help,
 	Error,
 	Border,
+	Row,
 	Highlight,
 	Muted lipgloss.Style
 }
@@ -128,6 +132,9 @@ func NewStyles() *Styles {
 		BorderStyle(lipgloss.NormalBorder()).
 		BorderForeground(primaryColor)
 
+	// List rows are not padded like Base, which already wraps the whole view
+	s.Row = lipgloss.NewStyle()
+
 	s.Highlight = lipgloss.NewStyle().
 		Background(primaryColor).
 		Foreground(lipgloss.Color("0"))
@@ -153,13 +160,27 @@ type App struct {
 	error           error
 	message         string
 	quitting        bool
+	now             func() time.Time
+
+	// Pomodoro timer
+	pomodoro PomodoroOptions
+	timer    *timer
+	timerID  int
+
+	notifyWarned map[string]bool
 
 	// Form data
+	editingTask     *models.Task // Task the form edits; nil when it creates one
 	formTitle       string
 	formDescription string
 	formWorkScore   int
 	formPlayScore   int
 	formLearnScore  int
+
+	// Interruption form, shown over the timer
+	interruptForm        *huh.Form
+	formReason           string
+	formInterruptionKind models.InterruptionKind
 }
 
 // NewApp creates a new TUI application
@@ -170,7 +191,11 @@ func NewApp(storage storage.Storage) *App {
 		currentView: ViewModeMain,
 		width:       80,
 		height:      24,
+		now:         time.Now,
+
+		notifyWarned: make(map[string]bool),
 	}
+	app.SetPomodoro(PomodoroOptions{Config: pomodoro.DefaultConfig()})
 
 	// Load initial data
 	app.loadData()
@@ -197,6 +222,18 @@ func (a *App) loadData() {
 	}
 }
 
+// refreshStats recomputes today's stats snapshot after a task change, along
+// with those of the other days the changed task counts towards
+func (a *App) refreshStats(changed ...*models.Task) {
+	days := []time.Time{time.Now()}
+	for _, task := range changed {
+		days = append(days, stats.TaskDays(task, time.Now())...)
+	}
+	if err := stats.RefreshDaily(a.storage, days...); err != nil {
+		a.error = err
+	}
+}
+
 // Init initializes the application
 func (a *App) Init() tea.Cmd {
 	return nil
@@ -208,12 +245,15 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		a.width = msg.Width
 		a.height = msg.Height
+		if a.currentView == ViewModeCreateTask && a.form != nil {
+			return a.updateForm(msg)
+		}
 		return a, nil
 
 	case tea.KeyMsg:
 		// Global Ctrl+C handling
 		if msg.String() == "ctrl+c" {
-			return a, tea.Quit
+			return a, a.quit()
 		}
 
 		switch a.currentView {
@@ -231,21 +271,41 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a.updateTaskDetail(msg)
 		case ViewModeSettings:
 			return a.updateSettings(msg)
+		case ViewModeTimer:
+			return a.updateTimer(msg)
 		}
 
+	case sessionMsg:
+		return a.updateSession(msg)
+
 	case tea.QuitMsg:
 		a.quitting = true
 		return a, tea.Quit
 	}
 
+	// The form advances between fields and groups through its own
+	// messages, so it needs to see everything, not just key presses
+	if a.currentView == ViewModeCreateTask && a.form != nil {
+		return a.updateForm(msg)
+	}
+	if a.currentView == ViewModeTimer && a.interruptForm != nil {
+		return a.updateInterruptForm(msg)
+	}
+
 	return a, nil
 }
 
+// quit stops a running timer, leaving its task paused, and exits
+func (a *App) quit() tea.Cmd {
+	a.stopTimer()
+	return tea.Quit
+}
+
 // updateMain handles the main menu navigation
 func (a *App) updateMain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "q", "ctrl+c":
-		return a, tea.Quit
+		return a, a.quit()
 	case "t":
 		a.currentView = ViewModeTaskList
 		a.selectedIndex = 0
@@ -258,6 +318,8 @@ func (a *App) updateMain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return a, a.initCreateTaskForm()
 	case "g":
 		a.currentView = ViewModeSettings
+	case "p":
+		a.currentView = ViewModeTimer
 	case "r":
 		a.loadData()
 		a.message = "Data reloaded"
@@ -286,12 +348,17 @@ func (a *App) updateTaskList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "c":
 		a.currentView = ViewModeCreateTask
 		return a, a.initCreateTaskForm()
+	case "p":
+		if a.selectedIndex < len(a.tasks) {
+			return a, a.startTimer(a.tasks[a.selectedIndex])
+		}
 	case "d":
 		if a.selectedIndex < len(a.tasks) {
 			task := a.tasks[a.selectedIndex]
 			if err := a.storage.DeleteTask(task.ID); err != nil {
 				a.error = err
 			} else {
+				a.refreshStats(task)
 				a.loadData()
 				a.message = "Task deleted"
 				if a.selectedIndex >= len(a.tasks) {
@@ -313,6 +380,7 @@ func (a *App) updateTaskList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if err := a.storage.UpdateTask(task); err != nil {
 				a.error = err
 			} else {
+				a.refreshStats(task)
 				a.loadData()
 				a.message = "Task updated"
 			}
@@ -343,29 +411,48 @@ func (a *App) updateStats(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (a *App) updateCreateTask(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Handle escape to exit form
 	if msg.String() == "esc" {
-		a.currentView = ViewModeTaskList
+		a.currentView = a.formReturnView()
 		return a, nil
 	}
 
 	// Let form handle all other keys (including Tab, Enter, etc.)
 	if a.form != nil {
-		form, cmd := a.form.Update(msg)
-		if f, ok := form.(*huh.Form); ok {
-			a.form = f
+		return a.updateForm(msg)
+	}
 
-			// Check if form is completed
-			if a.form.State == huh.StateCompleted {
+	return a, nil
+}
+
+// updateForm passes a message to the task form and creates or updates the
+// task once the form is completed
+func (a *App) updateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	form, cmd := a.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		a.form = f
+
+		switch a.form.State {
+		case huh.StateCompleted:
+			if a.editingTask != nil {
+				a.updateTaskFromForm()
+			} else {
 				a.createTaskFromForm()
-				a.currentView = ViewModeTaskList
 			}
-
-			return a, cmd
+			a.currentView = a.formReturnView()
+		case huh.StateAborted:
+			a.currentView = a.formReturnView()
 		}
-		// If cast failed, still return the cmd
-		return a, cmd
 	}
 
-	return a, nil
+	return a, cmd
+}
+
+// formReturnView is the view to show when the task form closes: the task
+// being edited, or the task list after creating one
+func (a *App) formReturnView() ViewMode {
+	if a.editingTask != nil {
+		return ViewModeTaskDetail
+	}
+	return ViewModeTaskList
 }
 
 // updateTaskDetail handles task detail view
@@ -373,6 +460,15 @@ func (a *App) updateTaskDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "q", "esc":
 		a.currentView = ViewModeTaskList
+	case "p":
+		if a.currentTask != nil {
+			return a, a.startTimer(a.currentTask)
+		}
+	case "e":
+		if a.currentTask != nil {
+			a.currentView = ViewModeCreateTask
+			return a, a.initEditTaskForm(a.currentTask)
+		}
 	case " ":
 		if a.currentTask != nil {
 			if a.currentTask.Status == models.TaskStatusCompleted {
@@ -383,6 +479,7 @@ func (a *App) updateTaskDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if err := a.storage.UpdateTask(a.currentTask); err != nil {
 				a.error = err
 			} else {
+				a.refreshStats(a.currentTask)
 				a.loadData()
 				a.message = "Task updated"
 			}
@@ -392,6 +489,7 @@ func (a *App) updateTaskDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if err := a.storage.DeleteTask(a.currentTask.ID); err != nil {
 				a.error = err
 			} else {
+				a.refreshStats(a.currentTask)
 				a.loadData()
 				a.message = "Task deleted"
 				a.currentView = ViewModeTaskList
@@ -433,6 +531,8 @@ func (a *App) View() string {
 		content = a.viewTaskDetail()
 	case ViewModeSettings:
 		content = a.viewSettings()
+	case ViewModeTimer:
+		content = a.viewTimer()
 	}
 
 	// Add error message if present
@@ -471,6 +571,7 @@ Navigation:
   [s] Schedule    - View canonical hours
   [d] Statistics  - View productivity stats
   [c] Create      - Add new task
+  [p] Pomodoro    - Show the timer
   [g] Settings    - Configure app
   [r] Refresh     - Reload data
   [q] Quit        - Exit application
@@ -482,9 +583,11 @@ Navigation:
 		a.styles.Subtitle.Render(currentHour),
 		"",
 		taskSummary,
-		"",
-		menu,
 	}
+	if summary := a.timerSummary(); summary != "" {
+		content = append(content, a.styles.Subtitle.Render(summary))
+	}
+	content = append(content, "", menu)
 
 	return strings.Join(content, "\n")
 }
@@ -523,7 +626,7 @@ func (a *App) viewTaskList() string {
 
 	var taskList []string
 	for i, task := range a.tasks {
-		style := a.styles.Base
+		style := a.styles.Row
 		if i == a.selectedIndex {
 			style = a.styles.Highlight
 		}
@@ -538,7 +641,8 @@ func (a *App) viewTaskList() string {
 
 		scores := fmt.Sprintf("W:%d P:%d L:%d", task.Score.Work, task.Score.Play, task.Score.Learn)
 
-		line := fmt.Sprintf("%s %s %s",
+		line := fmt.Sprintf("%s %s %s %s",
+			a.styles.Muted.Render(fmt.Sprintf("%4s", task.Ref())),
 			statusStyle.Render(fmt.Sprintf("[%s]", task.Status.String()[:1])),
 			task.Title,
 			a.styles.Muted.Render(scores))
@@ -546,7 +650,7 @@ func (a *App) viewTaskList() string {
 		taskList = append(taskList, style.Render(line))
 	}
 
-	help := a.styles.Help.Render("↑/↓: navigate, Enter: details, Space: toggle, c: create, d: delete, q: back")
+	help := a.styles.Help.Render("↑/↓: navigate, Enter: details, Space: toggle, p: pomodoro, c: create, d: delete, q: back")
 
 	return title + "\n\n" + strings.Join(taskList, "\n") + "\n\n" + help
 }
@@ -563,7 +667,7 @@ func (a *App) viewSchedule() string {
 	now := time.Now()
 
 	for _, hour := range a.currentSchedule.Hours {
-		style := a.styles.Base
+		style := a.styles.Row
 		if hour.IsActive(now) {
 			style = a.styles.Highlight
 		}
@@ -610,9 +714,12 @@ func (a *App) viewStats() string {
 	return strings.Join(content, "\n")
 }
 
-// viewCreateTask renders the task creation form
+// viewCreateTask renders the task form, for creating or editing a task
 func (a *App) viewCreateTask() string {
 	title := a.styles.Header.Render("Create New Task")
+	if a.editingTask != nil {
+		title = a.styles.Header.Render("Edit Task " + a.editingTask.Ref())
+	}
 
 	if a.form == nil {
 		return title + "\n\n" + a.styles.Error.Render("Form not initialized")
@@ -642,7 +749,7 @@ func (a *App) viewTaskDetail() string {
 	content := []string{
 		title,
 		"",
-		a.styles.Title.Render(task.Title),
+		a.styles.Title.Render(task.Ref() + " " + task.Title),
 		statusStyle.Render(fmt.Sprintf("Status: %s", task.Status.String())),
 		"",
 		fmt.Sprintf("Scores: %s %s %s",
@@ -662,7 +769,7 @@ func (a *App) viewTaskDetail() string {
 		content = append(content, "", "Notes:", task.Notes)
 	}
 
-	content = append(content, "", a.styles.Help.Render("Space: toggle status, d: delete, q: back"))
+	content = append(content, "", a.styles.Help.Render("Space: toggle status, e: edit, p: pomodoro, d: delete, q: back"))
 
 	return strings.Join(content, "\n")
 }
@@ -685,13 +792,34 @@ func (a *App) viewSettings() string {
 // initCreateTaskForm initializes the task creation form
 func (a *App) initCreateTaskForm() tea.Cmd {
 	// Reset form data
+	a.editingTask = nil
 	a.formTitle = ""
 	a.formDescription = ""
 	a.formWorkScore = 0
 	a.formPlayScore = 0
 	a.formLearnScore = 0
 
-	a.form = huh.NewForm(
+	a.form = a.newTaskForm()
+	return a.form.Init()
+}
+
+// initEditTaskForm initializes the task form prefilled from task
+func (a *App) initEditTaskForm(task *models.Task) tea.Cmd {
+	a.editingTask = task
+	a.formTitle = task.Title
+	a.formDescription = task.Description
+	a.formWorkScore = task.Score.Work
+	a.formPlayScore = task.Score.Play
+	a.formLearnScore = task.Score.Learn
+
+	a.form = a.newTaskForm()
+	return a.form.Init()
+}
+
+// newTaskForm builds the form for the title, description and scores of a
+// task, bound to the form data of a
+func (a *App) newTaskForm() *huh.Form {
+	return huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
 				Key("title").
@@ -760,8 +888,6 @@ func (a *App) initCreateTaskForm() tea.Cmd {
 				Value(&a.formLearnScore),
 		),
 	)
-
-	return a.form.Init()
 }
 
 // createTaskFromForm creates a task from the form data
@@ -794,14 +920,43 @@ func (a *App) createTaskFromForm() {
 	if err := a.storage.CreateTask(task); err != nil {
 		a.error = err
 	} else {
+		a.refreshStats()
 		a.loadData()
 		a.message = "Task created successfully"
 	}
 }
 
+// updateTaskFromForm saves the form data to the task being edited
+func (a *App) updateTaskFromForm() {
+	title := strings.TrimSpace(a.formTitle)
+	if title == "" {
+		a.error = fmt.Errorf("task title cannot be empty")
+		return
+	}
+
+	task := a.editingTask
+	task.Title = title
+	task.Description = a.formDescription
+	task.Score = models.Score{
+		Work:  a.formWorkScore,
+		Play:  a.formPlayScore,
+		Learn: a.formLearnScore,
+	}
+
+	if err := a.storage.UpdateTask(task); err != nil {
+		a.error = err
+	} else {
+		a.refreshStats(task)
+		a.loadData()
+		a.message = "Task updated"
+	}
+}
+
 // Run starts the TUI application
 func (a *App) Run() error {
 	p := tea.NewProgram(a, tea.WithAltScreen())
 	_, err := p.Run()
+	// Leave the task paused when the program was killed mid-timer
+	a.stopTimer()
 	return err
 }
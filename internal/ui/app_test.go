@@ -0,0 +1,277 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+
+	"qomoboro/internal/models"
+	"qomoboro/internal/storage"
+)
+
+// newTestApp opens the TUI on a fresh file storage holding the given tasks
+func newTestApp(t *testing.T, titles ...string) (*teatest.TestModel, storage.Storage) {
+	t.Helper()
+
+	store, err := storage.NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+	for i, title := range titles {
+		now := time.Now()
+		task := &models.Task{
+			ID:        "task_" + string(rune('a'+i)),
+			Title:     title,
+			Score:     models.Score{Work: 3, Play: 1, Learn: 2},
+			Status:    models.TaskStatusPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := store.CreateTask(task); err != nil {
+			t.Fatalf("CreateTask() error = %v", err)
+		}
+	}
+
+	tm := teatest.NewTestModel(t, NewApp(store), teatest.WithInitialTermSize(100, 40))
+	t.Cleanup(func() {
+		tm.Quit()
+	})
+	return tm, store
+}
+
+// waitForText waits until the rendered output contains all of texts. The
+// output read while waiting is consumed, so texts that are drawn together
+// must be waited for together.
+func waitForText(t *testing.T, tm *teatest.TestModel, texts ...string) {
+	t.Helper()
+	teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+		for _, text := range texts {
+			if !bytes.Contains(out, []byte(text)) {
+				return false
+			}
+		}
+		return true
+	}, teatest.WithDuration(3*time.Second))
+}
+
+// press sends a single key press
+func press(tm *teatest.TestModel, key string) {
+	switch key {
+	case "enter":
+		tm.Send(tea.KeyMsg{Type: tea.KeyEnter})
+	case "esc":
+		tm.Send(tea.KeyMsg{Type: tea.KeyEsc})
+	case "down":
+		tm.Send(tea.KeyMsg{Type: tea.KeyDown})
+	case " ":
+		tm.Send(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	default:
+		tm.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	}
+}
+
+// finalApp quits the program and returns the model it ended with
+func finalApp(t *testing.T, tm *teatest.TestModel) *App {
+	t.Helper()
+	tm.Send(tea.KeyMsg{Type: tea.KeyCtrlC})
+	return tm.FinalModel(t, teatest.WithFinalTimeout(3*time.Second)).(*App)
+}
+
+func TestApp_TaskListToggleComplete(t *testing.T) {
+	tm, store := newTestApp(t, "Fix bug", "Write docs")
+	waitForText(t, tm, "Navigation")
+
+	press(tm, "t")
+	waitForText(t, tm, "Write docs")
+
+	press(tm, "down")
+	press(tm, " ")
+	waitForText(t, tm, "Task updated")
+
+	finalApp(t, tm)
+
+	task, err := store.GetTask("task_b")
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if task.Status != models.TaskStatusCompleted {
+		t.Errorf("task status = %v, want %v", task.Status, models.TaskStatusCompleted)
+	}
+
+	stats, err := store.GetDailyStats(time.Now())
+	if err != nil {
+		t.Fatalf("GetDailyStats() error = %v", err)
+	}
+	if stats.CompletedTasks != 1 {
+		t.Errorf("CompletedTasks = %d, want 1 after completing from the TUI", stats.CompletedTasks)
+	}
+}
+
+func TestApp_CreateTaskForm(t *testing.T) {
+	tm, store := newTestApp(t)
+	waitForText(t, tm, "Navigation")
+
+	press(tm, "c")
+	waitForText(t, tm, "Task Title")
+	tm.Type("Write tests")
+	press(tm, "enter")
+
+	waitForText(t, tm, "Description")
+	tm.Type("with teatest")
+	press(tm, "enter")
+
+	// Work 4, Play 0, Learn 2
+	waitForText(t, tm, "Work Score")
+	for i := 0; i < 4; i++ {
+		press(tm, "down")
+	}
+	press(tm, "enter")
+	waitForText(t, tm, "Play Score")
+	press(tm, "enter")
+	waitForText(t, tm, "Learn Score")
+	press(tm, "down")
+	press(tm, "down")
+	press(tm, "enter")
+
+	waitForText(t, tm, "Task created successfully")
+	app := finalApp(t, tm)
+
+	if app.currentView != ViewModeTaskList {
+		t.Errorf("currentView = %v, want task list after creating a task", app.currentView)
+	}
+
+	tasks, err := store.ListTasks()
+	if err != nil {
+		t.Fatalf("ListTasks() error = %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("ListTasks() = %d tasks, want 1", len(tasks))
+	}
+	want := models.Score{Work: 4, Play: 0, Learn: 2}
+	if tasks[0].Title != "Write tests" || tasks[0].Description != "with teatest" || tasks[0].Score != want {
+		t.Errorf("created task = %q %q %+v, want %q %q %+v",
+			tasks[0].Title, tasks[0].Description, tasks[0].Score, "Write tests", "with teatest", want)
+	}
+}
+
+func TestApp_CreateTaskFormEscape(t *testing.T) {
+	tm, store := newTestApp(t)
+	waitForText(t, tm, "Navigation")
+
+	press(tm, "c")
+	waitForText(t, tm, "Task Title")
+	tm.Type("Never mind")
+	press(tm, "esc")
+	waitForText(t, tm, "Task List")
+
+	finalApp(t, tm)
+
+	if tasks, _ := store.ListTasks(); len(tasks) != 0 {
+		t.Errorf("ListTasks() = %d tasks, want none after escaping the form", len(tasks))
+	}
+}
+
+func TestApp_EditTaskForm(t *testing.T) {
+	tm, store := newTestApp(t, "Fix bug")
+	waitForText(t, tm, "Navigation")
+
+	press(tm, "t")
+	waitForText(t, tm, "Fix bug")
+	press(tm, "enter")
+	waitForText(t, tm, "Task Details")
+
+	press(tm, "e")
+	waitForText(t, tm, "Edit Task #1", "Fix bug")
+	tm.Type(" now")
+	press(tm, "enter")
+	waitForText(t, tm, "Description")
+	press(tm, "enter")
+
+	// The scores start at Work 3, Play 1, Learn 2; raise Work to 4
+	waitForText(t, tm, "Work Score")
+	press(tm, "down")
+	press(tm, "enter")
+	waitForText(t, tm, "Play Score")
+	press(tm, "enter")
+	waitForText(t, tm, "Learn Score")
+	press(tm, "enter")
+
+	waitForText(t, tm, "Task updated")
+	app := finalApp(t, tm)
+
+	if app.currentView != ViewModeTaskDetail {
+		t.Errorf("currentView = %v, want task detail after editing a task", app.currentView)
+	}
+
+	task, err := store.GetTask("task_a")
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	want := models.Score{Work: 4, Play: 1, Learn: 2}
+	if task.Title != "Fix bug now" || task.Score != want {
+		t.Errorf("edited task = %q %+v, want %q %+v", task.Title, task.Score, "Fix bug now", want)
+	}
+}
+
+func TestApp_EditTaskFormEscape(t *testing.T) {
+	tm, store := newTestApp(t, "Fix bug")
+	waitForText(t, tm, "Navigation")
+
+	press(tm, "t")
+	waitForText(t, tm, "Fix bug")
+	press(tm, "enter")
+	waitForText(t, tm, "Task Details")
+
+	press(tm, "e")
+	waitForText(t, tm, "Edit Task")
+	tm.Type(" never mind")
+	press(tm, "esc")
+	waitForText(t, tm, "Task Details")
+
+	finalApp(t, tm)
+
+	if task, _ := store.GetTask("task_a"); task.Title != "Fix bug" {
+		t.Errorf("task title = %q, want it unchanged after escaping the form", task.Title)
+	}
+}
+
+func TestApp_TaskDetailDelete(t *testing.T) {
+	tm, store := newTestApp(t, "Fix bug")
+	waitForText(t, tm, "Navigation")
+
+	press(tm, "t")
+	waitForText(t, tm, "Fix bug")
+	press(tm, "enter")
+	waitForText(t, tm, "Task Details")
+
+	press(tm, "d")
+	waitForText(t, tm, "Task deleted")
+
+	finalApp(t, tm)
+
+	if _, err := store.GetTask("task_a"); err == nil {
+		t.Errorf("GetTask() after delete error = nil, want not found")
+	}
+}
+
+func TestApp_ScheduleAndStatsViews(t *testing.T) {
+	tm, _ := newTestApp(t, "Fix bug")
+	waitForText(t, tm, "Navigation")
+
+	press(tm, "s")
+	waitForText(t, tm, "Canonical Hours Schedule", "Compline")
+
+	press(tm, "q")
+	waitForText(t, tm, "Navigation")
+
+	press(tm, "d")
+	waitForText(t, tm, "Statistics", "Tasks:")
+
+	app := finalApp(t, tm)
+	if app.currentView != ViewModeStats {
+		t.Errorf("currentView = %v, want stats view", app.currentView)
+	}
+}
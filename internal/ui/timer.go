@@ -0,0 +1,616 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+
+	"qomoboro/internal/models"
+	"qomoboro/pomodoro"
+)
+
+// PomodoroOptions configures the timer view
+type PomodoroOptions struct {
+	Config    pomodoro.Config
+	Rounds    int               // Focus rounds per run; zero runs one full cycle
+	Notifier  pomodoro.Notifier // Defaults to NopNotifier
+	StatePath string            // Where to save the running timer for pomo status; empty to not save it
+	Clock     pomodoro.Clock    // Defaults to the system clock
+}
+
+// timer is a pomodoro run in the timer view: a pomodoro.Session running in
+// the background, whose hooks and ticks come back to the app as messages
+type timer struct {
+	id      int // Messages carry the id of the timer that sent them
+	task    *models.Task
+	session *pomodoro.Session
+	ctx     context.Context
+	cancel  context.CancelFunc
+	msgs    chan tea.Msg       // Hook calls and the end of the run, in order
+	ticks   chan pomodoro.Tick // Countdown of the running phase
+	state   pomodoro.State     // Shown in the view and saved for pomo status
+	waiting bool               // Paused before the phase started because AutoStart is off
+	savedAt time.Time
+}
+
+// timerTickMsg counts the running phase down
+type timerTickMsg struct {
+	id   int
+	tick pomodoro.Tick
+}
+
+// phaseReadyMsg reports a phase waiting for the user to start it
+type phaseReadyMsg struct {
+	id    int
+	phase pomodoro.Phase
+	round int
+}
+
+// phaseStartedMsg reports the start of a phase
+type phaseStartedMsg struct {
+	id    int
+	phase pomodoro.Phase
+	round int
+}
+
+// phaseEndedMsg reports the end of a phase; completed is false when it was
+// skipped
+type phaseEndedMsg struct {
+	id        int
+	phase     pomodoro.Phase
+	round     int
+	completed bool
+}
+
+// timerDoneMsg reports that the session has run through its last break or
+// failed to start
+type timerDoneMsg struct {
+	id  int
+	err error
+}
+
+// notifyFailedMsg reports a phase change that could not be announced
+type notifyFailedMsg struct {
+	id  int
+	err error
+}
+
+// sessionMsg is a message from the session of the timer with the given id
+type sessionMsg interface {
+	timerID() int
+}
+
+func (m timerTickMsg) timerID() int    { return m.id }
+func (m phaseReadyMsg) timerID() int   { return m.id }
+func (m phaseStartedMsg) timerID() int { return m.id }
+func (m phaseEndedMsg) timerID() int   { return m.id }
+func (m timerDoneMsg) timerID() int    { return m.id }
+func (m notifyFailedMsg) timerID() int { return m.id }
+
+// SetPomodoro configures the timer started from the task views
+func (a *App) SetPomodoro(opts PomodoroOptions) {
+	if opts.Notifier == nil {
+		opts.Notifier = pomodoro.NopNotifier{}
+	}
+	a.pomodoro = opts
+}
+
+// startTimer begins a run of focus rounds on task and switches to the
+// timer view
+func (a *App) startTimer(task *models.Task) tea.Cmd {
+	if a.timer != nil {
+		a.error = fmt.Errorf("a pomodoro is already running on %s", a.timer.task.Title)
+		return nil
+	}
+	if task.Status == models.TaskStatusCompleted || task.Status == models.TaskStatusCancelled {
+		a.error = fmt.Errorf("%s is %s", task.Title, task.Status)
+		return nil
+	}
+	if a.pomodoro.StatePath != "" {
+		state, err := pomodoro.LoadState(a.pomodoro.StatePath)
+		if err != nil {
+			a.error = err
+			return nil
+		}
+		if state != nil && !state.Interrupted {
+			a.error = fmt.Errorf("a pomodoro is already running on %s (pid %d)", state.TaskTitle, state.PID)
+			return nil
+		}
+	}
+	if err := a.pauseOtherTasks(task); err != nil {
+		a.error = err
+		return nil
+	}
+
+	cfg := a.pomodoro.Config
+	rounds := a.pomodoro.Rounds
+	if rounds <= 0 {
+		rounds = cfg.LongBreakEvery
+	}
+
+	now := a.now()
+	a.timerID++
+	t := &timer{
+		id:    a.timerID,
+		task:  task,
+		msgs:  make(chan tea.Msg, 16),
+		ticks: make(chan pomodoro.Tick, 16),
+		state: pomodoro.State{
+			PID:            os.Getpid(),
+			TaskID:         task.ID,
+			TaskTitle:      task.Title,
+			Config:         cfg,
+			Rounds:         rounds,
+			Round:          1,
+			Phase:          pomodoro.PhaseFocus,
+			Remaining:      cfg.Focus,
+			StartedAt:      now,
+			PhaseStartedAt: now,
+		},
+	}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+
+	id := t.id
+	t.session = &pomodoro.Session{
+		Config:   cfg,
+		Rounds:   rounds,
+		Notifier: a.pomodoro.Notifier,
+		Ticks:    t.ticks,
+		Clock:    a.pomodoro.Clock,
+		Hooks: pomodoro.Hooks{
+			PhaseReady: func(phase pomodoro.Phase, round int) {
+				t.send(phaseReadyMsg{id: id, phase: phase, round: round})
+			},
+			PhaseStarted: func(phase pomodoro.Phase, round int) {
+				t.send(phaseStartedMsg{id: id, phase: phase, round: round})
+			},
+			PhaseEnded: func(phase pomodoro.Phase, round int, completed bool) {
+				t.send(phaseEndedMsg{id: id, phase: phase, round: round, completed: completed})
+			},
+			NotifyFailed: func(err error) {
+				t.send(notifyFailedMsg{id: id, err: err})
+			},
+		},
+	}
+
+	a.timer = t
+	a.currentView = ViewModeTimer
+	a.saveTimer()
+
+	return tea.Batch(t.run(), t.listen())
+}
+
+// run plays the session. Its end is sent after the hook calls rather than
+// returned, so that the app sees the last phase end before the run does.
+func (t *timer) run() tea.Cmd {
+	return func() tea.Msg {
+		err := t.session.Run(t.ctx)
+		t.send(timerDoneMsg{id: t.id, err: err})
+		return nil
+	}
+}
+
+// send queues msg for listen, giving up once the timer is stopped
+func (t *timer) send(msg tea.Msg) {
+	select {
+	case t.msgs <- msg:
+	case <-t.ctx.Done():
+	}
+}
+
+// listen waits for the next message from the session
+func (t *timer) listen() tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case msg := <-t.msgs:
+			return msg
+		case tick := <-t.ticks:
+			return timerTickMsg{id: t.id, tick: tick}
+		case <-t.ctx.Done():
+			return nil
+		}
+	}
+}
+
+// pauseOtherTasks pauses every active task but task, as only one task is
+// worked on at a time
+func (a *App) pauseOtherTasks(task *models.Task) error {
+	for _, t := range a.tasks {
+		if t.ID == task.ID || t.Status != models.TaskStatusActive {
+			continue
+		}
+		t.Pause()
+		if err := a.storage.UpdateTask(t); err != nil {
+			return fmt.Errorf("failed to pause %s: %w", t.Title, err)
+		}
+	}
+	return nil
+}
+
+// updateSession applies a message from the running session and listens for
+// the next one. Focus phases start or resume the task so that only focus
+// time is tracked.
+func (a *App) updateSession(msg sessionMsg) (tea.Model, tea.Cmd) {
+	t := a.timer
+	if t == nil || msg.timerID() != t.id {
+		// A message of a timer that has ended
+		return a, nil
+	}
+
+	now := a.now()
+	cfg := a.pomodoro.Config
+	switch msg := msg.(type) {
+	case timerTickMsg:
+		// Ticks are only counted for the phase the hooks last moved to, as a
+		// late one of the previous phase may still be queued
+		if msg.tick.Phase != t.state.Phase || msg.tick.Round != t.state.Round {
+			break
+		}
+		if t.state.Update(msg.tick, now) || now.Sub(t.savedAt) >= pomodoro.StateSaveInterval {
+			a.saveTimer()
+		}
+
+	case phaseReadyMsg:
+		t.waiting = true
+		t.state.Update(pomodoro.Tick{Phase: msg.phase, Round: msg.round, Rounds: t.state.Rounds,
+			Duration: cfg.Duration(msg.phase), Remaining: cfg.Duration(msg.phase), Paused: true}, now)
+		a.saveTimer()
+
+	case phaseStartedMsg:
+		t.waiting = false
+		t.state.Update(pomodoro.Tick{Phase: msg.phase, Round: msg.round, Rounds: t.state.Rounds,
+			Duration: cfg.Duration(msg.phase), Remaining: cfg.Duration(msg.phase)}, now)
+		if msg.phase == pomodoro.PhaseFocus {
+			a.updateTimerTask(func(task *models.Task) {
+				switch task.Status {
+				case models.TaskStatusPending:
+					task.Start()
+				case models.TaskStatusPaused:
+					task.Resume()
+				}
+				if a.currentSchedule != nil {
+					task.AssignCanonicalHours(a.currentSchedule)
+				}
+			})
+		}
+		a.saveTimer()
+
+	case phaseEndedMsg:
+		if msg.phase == pomodoro.PhaseFocus {
+			// An unfinished focus phase pauses the task without counting
+			a.updateTimerTask(func(task *models.Task) {
+				if msg.completed {
+					task.RecordPomodoro()
+				}
+				task.Pause()
+			})
+		}
+
+	case notifyFailedMsg:
+		// Once is enough on a machine without a speaker
+		if !a.notifyWarned[msg.err.Error()] {
+			a.notifyWarned[msg.err.Error()] = true
+			a.error = msg.err
+		}
+
+	case timerDoneMsg:
+		if msg.err != nil {
+			a.error = msg.err
+			a.endTimer("Pomodoro stopped")
+		} else {
+			a.endTimer("All done! Good job!")
+		}
+		return a, nil
+	}
+
+	return a, t.listen()
+}
+
+// pauseTimer pauses or resumes the countdown, starting a phase that is
+// waiting for the user
+func (a *App) pauseTimer() {
+	t := a.timer
+	if t.waiting || t.state.Paused {
+		t.session.Resume()
+		return
+	}
+	t.session.Pause()
+}
+
+// stopTimer ends the run early, leaving the task paused
+func (a *App) stopTimer() {
+	t := a.timer
+	if t == nil {
+		return
+	}
+
+	if t.state.Phase == pomodoro.PhaseFocus && !t.waiting {
+		a.updateTimerTask(func(task *models.Task) {
+			task.Pause()
+		})
+	}
+	a.endTimer("Pomodoro stopped")
+}
+
+// endTimer stops the session and forgets it along with its saved state
+func (a *App) endTimer(message string) {
+	a.timer.cancel()
+	a.timer = nil
+	a.message = message
+	if a.pomodoro.StatePath != "" {
+		if err := pomodoro.RemoveState(a.pomodoro.StatePath); err != nil {
+			a.error = err
+		}
+	}
+}
+
+// saveTimer writes the running timer to the state file so that pomo status
+// and pomo interrupt work from other shells
+func (a *App) saveTimer() {
+	t := a.timer
+	now := a.now()
+	t.savedAt = now
+	if a.pomodoro.StatePath == "" {
+		return
+	}
+
+	t.state.TaskTitle = t.task.Title
+	t.state.UpdatedAt = now
+	if err := pomodoro.SaveState(a.pomodoro.StatePath, &t.state); err != nil {
+		a.error = err
+	}
+}
+
+// updateTimerTask reloads the timer's task, applies change and saves it,
+// keeping edits made from other shells during the run
+func (a *App) updateTimerTask(change func(*models.Task)) {
+	task, err := a.storage.GetTask(a.timer.task.ID)
+	if err != nil {
+		a.error = fmt.Errorf("failed to load task: %w", err)
+		return
+	}
+
+	change(task)
+
+	if err := a.storage.UpdateTask(task); err != nil {
+		a.error = fmt.Errorf("failed to update task: %w", err)
+		return
+	}
+	a.timer.task = task
+	a.refreshStats()
+	a.loadData()
+}
+
+// updateTimer handles the timer view
+func (a *App) updateTimer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if a.interruptForm != nil {
+		if msg.String() == "esc" {
+			a.interruptForm = nil
+			return a, nil
+		}
+		return a.updateInterruptForm(msg)
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		a.currentView = ViewModeMain
+		return a, nil
+	}
+	if a.timer == nil {
+		return a, nil
+	}
+
+	switch msg.String() {
+	case " ", "p":
+		a.pauseTimer()
+	case "s":
+		a.timer.session.Skip()
+	case "x":
+		a.stopTimer()
+	case "i":
+		if a.timer.state.Phase.IsBreak() {
+			a.error = fmt.Errorf("interruptions are only logged during focus")
+			return a, nil
+		}
+		return a, a.initInterruptForm()
+	}
+	return a, nil
+}
+
+// initInterruptForm asks for the reason of an interruption
+func (a *App) initInterruptForm() tea.Cmd {
+	a.formReason = ""
+	a.formInterruptionKind = models.InterruptionExternal
+
+	a.interruptForm = huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Key("reason").
+				Title("Interruption").
+				Description("What broke your focus?").
+				Placeholder("Enter reason...").
+				Value(&a.formReason).
+				Validate(func(str string) error {
+					if len(strings.TrimSpace(str)) == 0 {
+						return fmt.Errorf("reason cannot be empty")
+					}
+					return nil
+				}),
+			huh.NewSelect[models.InterruptionKind]().
+				Key("kind").
+				Title("Kind").
+				Options(
+					huh.NewOption("External - someone or something else", models.InterruptionExternal),
+					huh.NewOption("Internal - the urge to do something else", models.InterruptionInternal),
+				).
+				Value(&a.formInterruptionKind),
+		),
+	)
+
+	return a.interruptForm.Init()
+}
+
+// updateInterruptForm passes a message to the interruption form and logs
+// the interruption once the form is completed
+func (a *App) updateInterruptForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	form, cmd := a.interruptForm.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		a.interruptForm = f
+
+		switch a.interruptForm.State {
+		case huh.StateCompleted:
+			a.interruptForm = nil
+			a.logInterruption()
+		case huh.StateAborted:
+			a.interruptForm = nil
+		}
+	}
+
+	return a, cmd
+}
+
+// logInterruption records the interruption from the form on the timer's
+// task
+func (a *App) logInterruption() {
+	if a.timer == nil {
+		return
+	}
+
+	now := a.now()
+	interruption := models.Interruption{
+		At:      now,
+		Reason:  strings.TrimSpace(a.formReason),
+		Kind:    a.formInterruptionKind,
+		Session: a.timer.state.StartedAt,
+		Round:   a.timer.state.Round,
+	}
+	if a.currentSchedule != nil {
+		if hour := a.currentSchedule.GetCurrentHour(now); hour != nil {
+			interruption.CanonicalHour = hour.Name
+		}
+	}
+
+	a.updateTimerTask(func(task *models.Task) {
+		task.RecordInterruption(interruption)
+	})
+	a.message = fmt.Sprintf("Logged %s interruption: %s", interruption.Kind, interruption.Reason)
+}
+
+// viewTimer renders the timer view
+func (a *App) viewTimer() string {
+	title := a.styles.Header.Render("Pomodoro")
+
+	t := a.timer
+	if t == nil {
+		return title + "\n\n" + a.styles.Muted.Render("No pomodoro running. Select a task and press 'p' to start one.") +
+			"\n\n" + a.styles.Help.Render("q: back to main menu")
+	}
+
+	phase := t.state.Phase.String()
+	phase = strings.ToUpper(phase[:1]) + phase[1:]
+
+	status := fmt.Sprintf("%s %d/%d", phase, t.state.Round, t.state.Rounds)
+	switch {
+	case t.waiting:
+		status += " - press space to start"
+	case t.state.Paused:
+		status += " - paused"
+	}
+
+	content := []string{
+		title,
+		a.styles.Title.Render(t.task.Title),
+		"",
+		a.styles.Subtitle.Render(status),
+		"",
+		a.styles.StatusActive.Render(bigClock(t.state.Remaining)),
+		"",
+		progressBar(a.pomodoro.Config.Duration(t.state.Phase)-t.state.Remaining, a.pomodoro.Config.Duration(t.state.Phase), 40),
+		"",
+		a.styles.Muted.Render(fmt.Sprintf("%d pomodoros on this task, %d interruptions this session",
+			t.task.Pomodoros, len(t.task.SessionInterruptions(t.state.StartedAt)))),
+	}
+
+	if a.interruptForm != nil {
+		content = append(content, "", a.interruptForm.View())
+	} else {
+		content = append(content, "", a.styles.Help.Render("space: pause/resume, s: skip, x: stop, i: interruption, q: back"))
+	}
+
+	return strings.Join(content, "\n")
+}
+
+// timerSummary describes the running timer in one line for the main menu
+func (a *App) timerSummary() string {
+	t := a.timer
+	if t == nil {
+		return ""
+	}
+
+	summary := fmt.Sprintf("Pomodoro: %s %d/%d on %s, %s left", t.state.Phase, t.state.Round, t.state.Rounds, t.task.Title, formatClock(t.state.Remaining))
+	if t.state.Paused {
+		summary += " (paused)"
+	}
+	return summary
+}
+
+// formatClock renders d as minutes and seconds, e.g. "24:59"
+func formatClock(d time.Duration) string {
+	seconds := int((d + time.Second - 1) / time.Second) // Round up so 0:00 means done
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// bigDigits are the glyphs of bigClock, five rows each
+var bigDigits = map[rune][5]string{
+	'0': {"███", "█ █", "█ █", "█ █", "███"},
+	'1': {"  █", "  █", "  █", "  █", "  █"},
+	'2': {"███", "  █", "███", "█  ", "███"},
+	'3': {"███", "  █", "███", "  █", "███"},
+	'4': {"█ █", "█ █", "███", "  █", "  █"},
+	'5': {"███", "█  ", "███", "  █", "███"},
+	'6': {"███", "█  ", "███", "█ █", "███"},
+	'7': {"███", "  █", "  █", "  █", "  █"},
+	'8': {"███", "█ █", "███", "█ █", "███"},
+	'9': {"███", "█ █", "███", "  █", "███"},
+	':': {" ", "█", " ", "█", " "},
+}
+
+// bigClock renders d as minutes and seconds in large block digits
+func bigClock(d time.Duration) string {
+	var rows [5][]string
+	for _, r := range formatClock(d) {
+		glyph := bigDigits[r]
+		for i := range rows {
+			rows[i] = append(rows[i], glyph[i])
+		}
+	}
+
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		lines[i] = strings.Join(row, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// progressBar renders how much of total is done as a bar width cells wide
+// followed by the percentage
+func progressBar(done, total time.Duration, width int) string {
+	if total <= 0 {
+		return ""
+	}
+	ratio := float64(done) / float64(total)
+	if ratio < 0 {
+		ratio = 0
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+
+	filled := int(ratio * float64(width))
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + fmt.Sprintf(" %3.0f%%", ratio*100)
+}
@@ -0,0 +1,327 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"qomoboro/internal/models"
+	"qomoboro/internal/storage"
+	"qomoboro/pomodoro"
+)
+
+// timerApp is an App driven by calling Update directly, with the messages
+// of the commands it returns queued for the test to deliver
+type timerApp struct {
+	*App
+	store storage.Storage
+	msgs  chan tea.Msg
+}
+
+// newTimerApp creates an app holding one pending task, timing pomodoros
+// with cfg and saving them to a state file in a temporary directory
+func newTimerApp(t *testing.T, cfg pomodoro.Config, clock pomodoro.Clock) *timerApp {
+	t.Helper()
+
+	dir := t.TempDir()
+	store, err := storage.NewFileStorage(dir)
+	if err != nil {
+		t.Fatalf("NewFileStorage() error = %v", err)
+	}
+	now := time.Now()
+	task := &models.Task{ID: "task_a", Title: "Fix bug", Status: models.TaskStatusPending, CreatedAt: now, UpdatedAt: now}
+	if err := store.CreateTask(task); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	ta := &timerApp{App: NewApp(store), store: store, msgs: make(chan tea.Msg, 64)}
+	ta.SetPomodoro(PomodoroOptions{Config: cfg, StatePath: filepath.Join(dir, pomodoro.StateFileName), Clock: clock})
+	t.Cleanup(ta.stopTimer)
+	return ta
+}
+
+// run runs cmd in the background like the program would, queueing the
+// messages it returns
+func (ta *timerApp) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	go func() {
+		switch msg := cmd().(type) {
+		case nil:
+		case tea.BatchMsg:
+			for _, cmd := range msg {
+				ta.run(cmd)
+			}
+		default:
+			ta.msgs <- msg
+		}
+	}()
+}
+
+// send updates the app with msg and runs the command it returns
+func (ta *timerApp) send(msg tea.Msg) {
+	_, cmd := ta.Update(msg)
+	ta.run(cmd)
+}
+
+// waitFor delivers queued messages one at a time until done holds
+func (ta *timerApp) waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for !done() {
+		select {
+		case msg := <-ta.msgs:
+			ta.send(msg)
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s; timer = %+v", what, ta.timer)
+		}
+	}
+}
+
+// waitForPhase waits until the timer has moved on to phase of round
+func (ta *timerApp) waitForPhase(t *testing.T, phase pomodoro.Phase, round int) {
+	t.Helper()
+	ta.waitFor(t, fmt.Sprintf("%v of round %d", phase, round), func() bool {
+		return ta.timer != nil && ta.timer.state.Phase == phase && ta.timer.state.Round == round
+	})
+}
+
+// key sends a single key press
+func (ta *timerApp) key(key string) {
+	if key == " " {
+		ta.send(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+		return
+	}
+	ta.send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+}
+
+// task reloads the timed task from storage
+func (ta *timerApp) task(t *testing.T) *models.Task {
+	t.Helper()
+	task, err := ta.store.GetTask("task_a")
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	return task
+}
+
+// jumpClock jumps forward by the requested wait instead of sleeping, so a
+// session runs whole phases at once
+type jumpClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *jumpClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *jumpClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func testTimerConfig() pomodoro.Config {
+	return pomodoro.Config{
+		Focus:          25 * time.Minute,
+		ShortBreak:     5 * time.Minute,
+		LongBreak:      15 * time.Minute,
+		LongBreakEvery: 2,
+		AutoStart:      true,
+	}
+}
+
+func TestApp_TimerFullCycle(t *testing.T) {
+	ta := newTimerApp(t, testTimerConfig(), &jumpClock{now: time.Now()})
+	ta.run(ta.startTimer(ta.tasks[0]))
+
+	if ta.currentView != ViewModeTimer {
+		t.Errorf("currentView = %v, want timer view", ta.currentView)
+	}
+	ta.waitFor(t, "the task to start", func() bool { return ta.timer.task.Status == models.TaskStatusActive })
+
+	steps := []struct {
+		phase pomodoro.Phase
+		round int
+	}{
+		{phase: pomodoro.PhaseShortBreak, round: 1},
+		{phase: pomodoro.PhaseFocus, round: 2},
+		{phase: pomodoro.PhaseLongBreak, round: 2},
+	}
+	for _, step := range steps {
+		ta.waitForPhase(t, step.phase, step.round)
+		if ta.timer.waiting || ta.timer.state.Remaining != ta.pomodoro.Config.Duration(step.phase) {
+			t.Errorf("timer at the start of %v = %+v, want the whole phase running", step.phase, ta.timer.state)
+		}
+		if got := ta.task(t).Status; step.phase == pomodoro.PhaseFocus && got != models.TaskStatusActive {
+			t.Errorf("task status during focus %d = %v, want active", step.round, got)
+		}
+	}
+
+	task := ta.task(t)
+	if task.Pomodoros != 2 || task.Status != models.TaskStatusPaused {
+		t.Errorf("task on long break = %d pomodoros, %v, want 2, paused", task.Pomodoros, task.Status)
+	}
+	if len(task.TimeEntries) != 2 {
+		t.Errorf("task has %d time entries, want one per focus round", len(task.TimeEntries))
+	}
+
+	ta.waitFor(t, "the end of the run", func() bool { return ta.timer == nil })
+	if ta.message != "All done! Good job!" {
+		t.Errorf("message after the run = %q, want All done! Good job!", ta.message)
+	}
+	if state, _ := pomodoro.LoadState(ta.pomodoro.StatePath); state != nil {
+		t.Errorf("state file after the run = %+v, want removed", state)
+	}
+}
+
+func TestApp_TimerPauseSkipStop(t *testing.T) {
+	ta := newTimerApp(t, testTimerConfig(), nil)
+	ta.run(ta.startTimer(ta.tasks[0]))
+	ta.waitFor(t, "the task to start", func() bool { return ta.timer.task.Status == models.TaskStatusActive })
+
+	ta.key(" ")
+	ta.waitFor(t, "the pause", func() bool { return ta.timer.state.Paused })
+	remaining := ta.timer.state.Remaining
+	if remaining <= 24*time.Minute || remaining > 25*time.Minute {
+		t.Errorf("paused timer remaining = %v, want nearly the whole focus phase", remaining)
+	}
+
+	state, err := pomodoro.LoadState(ta.pomodoro.StatePath)
+	if err != nil || state == nil {
+		t.Fatalf("LoadState() = %v, %v, want the running timer", state, err)
+	}
+	if !state.Paused || state.TaskID != "task_a" || state.Remaining != remaining {
+		t.Errorf("saved state = %+v, want paused on task_a with %v left", state, remaining)
+	}
+
+	ta.key(" ")
+	ta.waitFor(t, "the resume", func() bool { return !ta.timer.state.Paused })
+
+	ta.key("s")
+	ta.waitForPhase(t, pomodoro.PhaseShortBreak, 1)
+	if task := ta.task(t); task.Pomodoros != 0 || task.Status != models.TaskStatusPaused {
+		t.Errorf("task after skipped focus = %d pomodoros, %v, want 0, paused", task.Pomodoros, task.Status)
+	}
+
+	ta.key("x")
+	if ta.timer != nil {
+		t.Errorf("timer after stop = %+v, want nil", ta.timer)
+	}
+	if state, _ := pomodoro.LoadState(ta.pomodoro.StatePath); state != nil {
+		t.Errorf("state file after stop = %+v, want removed", state)
+	}
+}
+
+func TestApp_TimerWaitsWithoutAutoStart(t *testing.T) {
+	cfg := testTimerConfig()
+	cfg.AutoStart = false
+	ta := newTimerApp(t, cfg, &jumpClock{now: time.Now()})
+	ta.run(ta.startTimer(ta.tasks[0]))
+
+	ta.waitFor(t, "the break to wait", func() bool { return ta.timer.waiting })
+	if ta.timer.state.Phase != pomodoro.PhaseShortBreak || ta.timer.state.Remaining != 5*time.Minute {
+		t.Errorf("waiting timer = %+v, want the whole short break", ta.timer.state)
+	}
+	if state, _ := pomodoro.LoadState(ta.pomodoro.StatePath); state == nil || !state.Paused {
+		t.Errorf("saved state of the waiting break = %+v, want paused", state)
+	}
+
+	ta.key(" ")
+	ta.waitForPhase(t, pomodoro.PhaseFocus, 2)
+	ta.waitFor(t, "the focus to wait", func() bool { return ta.timer.waiting })
+
+	// Skipping a phase that is waiting passes over it without starting it
+	ta.key("s")
+	ta.waitForPhase(t, pomodoro.PhaseLongBreak, 2)
+	if task := ta.task(t); task.Pomodoros != 1 || len(task.TimeEntries) != 1 {
+		t.Errorf("task after skipping the waiting focus = %d pomodoros, %d time entries, want 1, 1",
+			task.Pomodoros, len(task.TimeEntries))
+	}
+}
+
+func TestApp_TimerRefusesSecondPomodoro(t *testing.T) {
+	ta := newTimerApp(t, testTimerConfig(), nil)
+	running := &pomodoro.State{PID: 1, TaskID: "other", TaskTitle: "Other", Rounds: 4, Round: 1}
+	if err := pomodoro.SaveState(ta.pomodoro.StatePath, running); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	ta.startTimer(ta.tasks[0])
+	if ta.timer != nil || ta.error == nil {
+		t.Errorf("startTimer() with a pomodoro running elsewhere = %+v, error %v, want an error", ta.timer, ta.error)
+	}
+}
+
+func TestApp_TimerInterruption(t *testing.T) {
+	tm, store := newTestApp(t, "Fix bug")
+	waitForText(t, tm, "Navigation")
+
+	press(tm, "t")
+	waitForText(t, tm, "Fix bug")
+	press(tm, "p")
+	waitForText(t, tm, "Focus 1/4", "Fix bug")
+
+	press(tm, "i")
+	waitForText(t, tm, "What broke your focus?")
+	tm.Type("phone call")
+	press(tm, "enter")
+	press(tm, "enter")
+	waitForText(t, tm, "Logged external interruption: phone call")
+
+	app := finalApp(t, tm)
+	if app.timer != nil {
+		t.Errorf("timer after quitting = %+v, want stopped", app.timer)
+	}
+
+	task, err := store.GetTask("task_a")
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if len(task.Interruptions) != 1 || task.Interruptions[0].Reason != "phone call" ||
+		task.Interruptions[0].Kind != models.InterruptionExternal {
+		t.Errorf("task interruptions = %+v, want one external phone call", task.Interruptions)
+	}
+	if task.Status != models.TaskStatusPaused {
+		t.Errorf("task status after quitting mid-focus = %v, want paused", task.Status)
+	}
+}
+
+func TestBigClock(t *testing.T) {
+	want := "" +
+		"███ ███   ███ ███\n" +
+		"  █ █ █ █ █   █ █\n" +
+		"███ █ █   ███ ███\n" +
+		"█   █ █ █   █   █\n" +
+		"███ ███   ███ ███"
+	if got := bigClock(20*time.Minute + 59*time.Second); got != want {
+		t.Errorf("bigClock(20:59) =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 25 * time.Minute, want: "25:00"},
+		{d: 90*time.Second + 500*time.Millisecond, want: "01:31"},
+		{d: 0, want: "00:00"},
+	}
+	for _, tt := range tests {
+		if got := formatClock(tt.d); got != tt.want {
+			t.Errorf("formatClock(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"qomoboro/internal/models"
+	"qomoboro/internal/output"
+)
+
+// outputFlag defines --output and -o on fs for choosing the format of a
+// command's result
+func outputFlag(fs *flag.FlagSet, format *output.Format) {
+	usage := "Write the result as `format`: text, json, yaml or tsv"
+	fs.Var(format, "output", usage)
+	fs.Var(format, "o", usage)
+}
+
+// statusReport is the result of status in machine-readable formats
+type statusReport struct {
+	Time        time.Time             `json:"time" yaml:"time"`
+	CurrentHour *models.CanonicalHour `json:"current_hour" yaml:"current_hour"` // Null outside canonical hours
+	Active      []*models.Task        `json:"active" yaml:"active"`
+	Paused      []*models.Task        `json:"paused" yaml:"paused"`
+	Pending     int                   `json:"pending" yaml:"pending"`
+	InProgress  int                   `json:"in_progress" yaml:"in_progress"` // Active and paused
+	Completed   int                   `json:"completed" yaml:"completed"`
+	Score       models.Score          `json:"score" yaml:"score"` // Total of the completed tasks
+}
+
+// newStatusReport summarizes tasks at now
+func newStatusReport(schedule *models.Schedule, tasks []*models.Task, now time.Time) *statusReport {
+	report := &statusReport{
+		Time:        now,
+		CurrentHour: schedule.GetCurrentHour(now),
+		Active:      []*models.Task{},
+		Paused:      []*models.Task{},
+	}
+	for _, task := range tasks {
+		switch task.Status {
+		case models.TaskStatusPending:
+			report.Pending++
+		case models.TaskStatusActive:
+			report.InProgress++
+			report.Active = append(report.Active, task)
+		case models.TaskStatusPaused:
+			report.InProgress++
+			report.Paused = append(report.Paused, task)
+		case models.TaskStatusCompleted:
+			report.Completed++
+			report.Score.Work += task.Score.Work
+			report.Score.Play += task.Score.Play
+			report.Score.Learn += task.Score.Learn
+		}
+	}
+	return report
+}
+
+// writeTasks writes tasks in format, as an empty list rather than null
+// when there are none
+func writeTasks(format output.Format, tasks []*models.Task) error {
+	if tasks == nil {
+		tasks = []*models.Task{}
+	}
+	return output.Write(os.Stdout, format, tasks, taskTable(tasks))
+}
+
+func taskTable(tasks []*models.Task) *output.Table {
+	table := &output.Table{Header: []string{
+		"short_id", "id", "status", "title", "work", "play", "learn",
+		"canonical_hour", "tags", "due_date", "estimated_duration", "created_at", "completed_at",
+	}}
+	for _, task := range tasks {
+		table.Rows = append(table.Rows, []string{
+			strconv.Itoa(task.ShortID),
+			task.ID,
+			task.Status.String(),
+			task.Title,
+			strconv.Itoa(task.Score.Work),
+			strconv.Itoa(task.Score.Play),
+			strconv.Itoa(task.Score.Learn),
+			task.CanonicalHour,
+			strings.Join(task.Tags, ","),
+			formatTSVDate(task.DueDate),
+			formatTSVDuration(task.EstimatedDuration),
+			task.CreatedAt.Format(time.RFC3339),
+			formatTSVTime(task.CompletedAt),
+		})
+	}
+	return table
+}
+
+func scheduleTable(schedule *models.Schedule) *output.Table {
+	table := &output.Table{Header: []string{"name", "start_time", "end_time", "description", "purpose"}}
+	for _, hour := range schedule.Hours {
+		table.Rows = append(table.Rows, []string{hour.Name, hour.StartTime, hour.EndTime, hour.Description, hour.Purpose})
+	}
+	return table
+}
+
+func statsTable(stats *models.DailyStats) *output.Table {
+	return &output.Table{
+		Header: []string{"date", "total_tasks", "completed_tasks", "work", "play", "learn", "time_spent", "interruptions"},
+		Rows: [][]string{{
+			stats.Date.Format("2006-01-02"),
+			strconv.Itoa(stats.TotalTasks),
+			strconv.Itoa(stats.CompletedTasks),
+			strconv.Itoa(stats.TotalScore.Work),
+			strconv.Itoa(stats.TotalScore.Play),
+			strconv.Itoa(stats.TotalScore.Learn),
+			formatTSVDuration(stats.TimeSpent),
+			strconv.Itoa(stats.Interruptions),
+		}},
+	}
+}
+
+func statusTable(report *statusReport) *output.Table {
+	var hour string
+	if report.CurrentHour != nil {
+		hour = report.CurrentHour.Name
+	}
+	var active []string
+	for _, task := range report.Active {
+		active = append(active, task.Ref())
+	}
+	return &output.Table{
+		Header: []string{"time", "current_hour", "active", "pending", "in_progress", "completed", "work", "play", "learn"},
+		Rows: [][]string{{
+			report.Time.Format(time.RFC3339),
+			hour,
+			strings.Join(active, ","),
+			strconv.Itoa(report.Pending),
+			strconv.Itoa(report.InProgress),
+			strconv.Itoa(report.Completed),
+			strconv.Itoa(report.Score.Work),
+			strconv.Itoa(report.Score.Play),
+			strconv.Itoa(report.Score.Learn),
+		}},
+	}
+}
+
+// eventTable lists the changed fields of each event by name; the old and
+// new values need the JSON or YAML output
+func eventTable(events []*models.TaskEvent) *output.Table {
+	table := &output.Table{Header: []string{"at", "action", "short_id", "task_id", "title", "source", "fields"}}
+	for _, event := range events {
+		var fields []string
+		for _, change := range event.Changes {
+			fields = append(fields, change.Field)
+		}
+		table.Rows = append(table.Rows, []string{
+			event.At.Format(time.RFC3339),
+			string(event.Action),
+			strconv.Itoa(event.ShortID),
+			event.TaskID,
+			event.Title,
+			event.Source,
+			strings.Join(fields, ","),
+		})
+	}
+	return table
+}
+
+// formatTSVDuration formats d in whole seconds so columns can be summed
+func formatTSVDuration(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+func formatTSVDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func formatTSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,584 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"qomoboro/internal/cli"
+	"qomoboro/internal/config"
+	"qomoboro/internal/models"
+	"qomoboro/internal/storage"
+	"qomoboro/pomodoro"
+)
+
+// pomoCommand runs pomodoros, with flags defaulting to the pomodoro config
+func pomoCommand(env *cliEnv) *cli.Command {
+	opts := pomodoroDefaults(env.cfg)
+	cmd := &cli.Command{
+		Name:    "pomo",
+		Aliases: []string{"pomodoro"},
+		Args:    "<id|title>",
+		Summary: "Run focus rounds on a task with short breaks and a long break after every fourth",
+		Help: `Defaults come from the "pomodoro" config. While it runs, type p to pause,
+Enter to resume, s to skip the phase and q to stop.`,
+		Examples: []string{"pomo bug --focus 50m --short-break 10m --rounds 2"},
+	}
+	fs := cmd.Flags()
+	cfg := &opts.Config
+	fs.DurationVar(&cfg.Focus, "focus", cfg.Focus, "Length of a focus phase")
+	fs.DurationVar(&cfg.ShortBreak, "short-break", cfg.ShortBreak, "Length of a short break")
+	fs.DurationVar(&cfg.LongBreak, "long-break", cfg.LongBreak, "Length of a long break")
+	fs.IntVar(&cfg.LongBreakEvery, "long-break-every", cfg.LongBreakEvery, "`N` focus phases before a long break")
+	fs.IntVar(&opts.Rounds, "rounds", opts.Rounds, "Run `N` focus rounds (default one full cycle)")
+	fs.BoolVar(&cfg.AutoStart, "auto-start", cfg.AutoStart, "Start the next phase without waiting for Enter")
+	notifyFlag(fs, &opts)
+
+	cmd.Run = func(args []string) error {
+		if len(args) == 0 {
+			return cli.Usagef("missing task")
+		}
+		if opts.Rounds < 0 {
+			return cli.Usagef("--rounds must not be negative")
+		}
+		if err := opts.Config.Validate(); err != nil {
+			return &cli.UsageError{Err: err}
+		}
+		return handlePomodoro(env.store, env.dataDir, opts, args)
+	}
+
+	// resume only takes the notifiers; the rest comes from the saved session
+	resumeOpts := pomodoroDefaults(env.cfg)
+	resume := &cli.Command{
+		Name:    "resume",
+		Summary: "Continue a pomodoro that was cut short",
+		Run: cli.NoArgs(func() error {
+			return handlePomodoroResume(env.store, statePath(env), resumeOpts)
+		}),
+	}
+	notifyFlag(resume.Flags(), &resumeOpts)
+
+	var internal, external bool
+	interrupt := &cli.Command{
+		Name:    "interrupt",
+		Args:    `"<reason>"`,
+		Summary: "Log an interruption of the running focus phase (external by default)",
+		Examples: []string{
+			`pomo interrupt "phone call"`,
+			`pomo interrupt --internal checked email`,
+		},
+		Run: func(args []string) error {
+			if internal && external {
+				return cli.Usagef("an interruption is either --internal or --external, not both")
+			}
+			kind := models.InterruptionExternal
+			if internal {
+				kind = models.InterruptionInternal
+			}
+			reason := strings.TrimSpace(strings.Join(args, " "))
+			if reason == "" {
+				return cli.Usagef("missing reason")
+			}
+			return handlePomodoroInterrupt(env.store, statePath(env), kind, reason)
+		},
+	}
+	interrupt.Flags().BoolVar(&internal, "internal", false, "You broke your own focus")
+	interrupt.Flags().BoolVar(&external, "external", false, "Someone or something else broke it")
+
+	cmd.Commands = []*cli.Command{
+		{
+			Name:    "status",
+			Summary: "Show the running pomodoro, also from another shell",
+			Run: cli.NoArgs(func() error {
+				return handlePomodoroStatus(env.store, statePath(env))
+			}),
+		},
+		resume,
+		interrupt,
+	}
+	return cmd
+}
+
+// notifyFlag defines the --notify flag, which replaces the notifiers of opts
+func notifyFlag(fs *flag.FlagSet, opts *pomodoroOptions) {
+	fs.Func("notify", "Comma-separated `list` of sound, bell, desktop or none (default from config)", func(value string) error {
+		opts.Notify = strings.Split(value, ",")
+		return nil
+	})
+}
+
+// statePath returns the path of the running pomodoro's state file
+func statePath(env *cliEnv) string {
+	return filepath.Join(env.dataDir, pomodoro.StateFileName)
+}
+
+func handlePomodoro(store storage.Storage, dataDir string, opts pomodoroOptions, args []string) error {
+	statePath := filepath.Join(dataDir, pomodoro.StateFileName)
+	if state, err := pomodoro.LoadState(statePath); err == nil && state != nil && !state.Interrupted {
+		return fmt.Errorf("a pomodoro is already running on %s (pid %d); see it with: qomoboro pomo status", state.TaskTitle, state.PID)
+	}
+
+	tasks, err := store.ListTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	var openTasks []*models.Task
+	for _, task := range tasks {
+		switch task.Status {
+		case models.TaskStatusPending, models.TaskStatusActive, models.TaskStatusPaused:
+			openTasks = append(openTasks, task)
+		}
+	}
+	if len(openTasks) == 0 {
+		fmt.Println("📝 No open tasks. Create one with: qomoboro add \"Task title\"")
+		return nil
+	}
+
+	task, err := selectTask(openTasks, args, "open", "focus on")
+	if err != nil {
+		return err
+	}
+
+	if err := pauseActiveTasks(store, tasks, task); err != nil {
+		return fmt.Errorf("failed to pause running task: %w", err)
+	}
+
+	return runPomodoro(store, statePath, task, opts, nil)
+}
+
+func handlePomodoroStatus(store storage.Storage, statePath string) error {
+	state, err := pomodoro.LoadState(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to load pomodoro: %w", err)
+	}
+	if state == nil {
+		fmt.Println("🍅 No pomodoro running. Start one with: qomoboro pomo <task>")
+		return nil
+	}
+
+	now := time.Now()
+	remaining := state.RemainingAt(now)
+	phase := strings.ToUpper(state.Phase.String()[:1]) + state.Phase.String()[1:]
+	summary := fmt.Sprintf("%s %d/%d on %s, %s left", phase, state.Round, state.Rounds, state.TaskTitle, formatDuration(remaining))
+
+	switch {
+	case state.Interrupted:
+		fmt.Printf("⚠️  Interrupted: %s\n", summary)
+		fmt.Printf("   Cut short at %s. Resume it with: qomoboro pomo resume\n", state.UpdatedAt.Local().Format("15:04"))
+	case state.Paused:
+		fmt.Printf("⏸️  Paused: %s\n", summary)
+	default:
+		fmt.Printf("🍅 %s %s\n", summary, colorize("(until "+now.Add(remaining).Format("15:04")+")", "dim"))
+	}
+	fmt.Printf("   %s\n", colorize(fmt.Sprintf("Started %s, pid %d", state.StartedAt.Local().Format("Jan 2 15:04"), state.PID), "dim"))
+
+	if task, err := store.GetTask(state.TaskID); err == nil {
+		if count := len(task.SessionInterruptions(state.StartedAt)); count > 0 {
+			fmt.Printf("   %s\n", colorize(fmt.Sprintf("%d interruptions this session", count), "dim"))
+		}
+	}
+	return nil
+}
+
+func handlePomodoroInterrupt(store storage.Storage, statePath string, kind models.InterruptionKind, reason string) error {
+	state, err := pomodoro.LoadState(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to load pomodoro: %w", err)
+	}
+	if state == nil || state.Interrupted {
+		return errors.New("no pomodoro running; start one with: qomoboro pomo <task>")
+	}
+	if state.Phase.IsBreak() {
+		return fmt.Errorf("you are on a %s; interruptions are only logged during focus", state.Phase)
+	}
+
+	now := time.Now()
+	interruption := models.Interruption{
+		At:      now,
+		Reason:  reason,
+		Kind:    kind,
+		Session: state.StartedAt,
+		Round:   state.Round,
+	}
+	if schedule, err := store.GetSchedule(); err == nil {
+		if hour := schedule.GetCurrentHour(now); hour != nil {
+			interruption.CanonicalHour = hour.Name
+		}
+	}
+
+	task := updatePomodoroTask(store, state.TaskID, func(t *models.Task) {
+		t.RecordInterruption(interruption)
+	})
+	if task == nil {
+		return nil
+	}
+	fmt.Printf("✋ Logged %s interruption on %s: %s %s\n", kind, task.Title, reason,
+		colorize(fmt.Sprintf("(%d this session)", len(task.SessionInterruptions(state.StartedAt))), "dim"))
+	return nil
+}
+
+func handlePomodoroResume(store storage.Storage, statePath string, opts pomodoroOptions) error {
+	state, err := pomodoro.LoadState(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to load pomodoro: %w", err)
+	}
+	if state == nil {
+		fmt.Println("🍅 No interrupted pomodoro to resume")
+		return nil
+	}
+	if !state.Interrupted {
+		fmt.Printf("🍅 The pomodoro on %s is still running %s\n", state.TaskTitle, colorize(fmt.Sprintf("(pid %d)", state.PID), "dim"))
+		return nil
+	}
+
+	tasks, err := store.ListTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	var task *models.Task
+	for _, t := range tasks {
+		if t.ID == state.TaskID {
+			task = t
+		}
+	}
+	if task == nil || task.IsCompleted() || task.Status == models.TaskStatusCancelled {
+		fmt.Printf("❌ %s is no longer open; discarding its pomodoro\n", state.TaskTitle)
+		if err := pomodoro.RemoveState(statePath); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+		return nil
+	}
+
+	if err := pauseActiveTasks(store, tasks, task); err != nil {
+		return fmt.Errorf("failed to pause running task: %w", err)
+	}
+
+	fmt.Printf("▶️  Resuming %s %d/%d on %s with %s left\n",
+		state.Phase, state.Round, state.Rounds, task.Title, formatDuration(state.Remaining))
+	opts.Config = state.Config
+	opts.Rounds = state.Rounds
+	return runPomodoro(store, statePath, task, opts, state)
+}
+
+// runPomodoro runs a session on task, continuing from an interrupted
+// session when from is set. The session is saved to statePath while it
+// runs so other shells can report on it and it can be resumed if this
+// process is interrupted or dies; only stopping or finishing it removes it.
+func runPomodoro(store storage.Storage, statePath string, task *models.Task, opts pomodoroOptions, from *pomodoro.State) error {
+	notifier, err := pomodoroNotifier(opts)
+	if err != nil {
+		return err
+	}
+
+	cfg, rounds := opts.Config, opts.Rounds
+	if rounds == 0 {
+		rounds = cfg.LongBreakEvery
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	session := pomodoro.NewSession(cfg, rounds)
+	session.Notifier = notifier
+	warned := make(map[string]bool)
+	session.Hooks = pomodoro.Hooks{
+		NotifyFailed: func(err error) {
+			// Once is enough on a machine without a speaker
+			for _, line := range strings.Split(err.Error(), "\n") {
+				if !warned[line] {
+					fmt.Printf("⚠️  %s (change \"notify\" in the pomodoro config to silence this)\n", line)
+					warned[line] = true
+				}
+			}
+		},
+		PhaseReady: func(phase pomodoro.Phase, round int) {
+			fmt.Printf("⏯️  Press Enter to start the %s\n", phase)
+		},
+		PhaseStarted: func(phase pomodoro.Phase, round int) {
+			if phase.IsBreak() {
+				fmt.Printf("☕ %s: %s\n", strings.ToUpper(phase.String()[:1])+phase.String()[1:], formatDuration(cfg.Duration(phase)))
+				return
+			}
+			updated := updatePomodoroTask(store, task.ID, func(t *models.Task) {
+				switch t.Status {
+				case models.TaskStatusPending:
+					t.Start()
+				case models.TaskStatusPaused:
+					t.Resume()
+				}
+				labelTimeEntries(store, t)
+			})
+			if updated != nil {
+				fmt.Printf("🍅 Focus %d/%d: %s %s\n", round, rounds, updated.Title, colorize(formatDuration(cfg.Focus), "dim"))
+			}
+		},
+		PhaseEnded: func(phase pomodoro.Phase, round int, completed bool) {
+			if phase.IsBreak() {
+				if completed {
+					fmt.Printf("⏰ %s over\n", strings.ToUpper(phase.String()[:1])+phase.String()[1:])
+				}
+				return
+			}
+			// An unfinished focus phase pauses the task without counting
+			updated := updatePomodoroTask(store, task.ID, func(t *models.Task) {
+				if completed {
+					t.RecordPomodoro()
+				}
+				t.Pause()
+			})
+			if updated != nil {
+				fmt.Printf("⏸️  Paused: %s %s\n", updated.Title,
+					colorize(fmt.Sprintf("(%d pomodoros, %s logged)", updated.Pomodoros, formatDuration(updated.ActualDuration)), "dim"))
+			}
+		},
+		Finished: func() {
+			fmt.Println("🎉 All rounds done! Good job!")
+		},
+	}
+
+	now := time.Now()
+	state := &pomodoro.State{
+		PID:            os.Getpid(),
+		TaskID:         task.ID,
+		TaskTitle:      task.Title,
+		Config:         cfg,
+		Rounds:         rounds,
+		Round:          1,
+		Phase:          pomodoro.PhaseFocus,
+		Remaining:      cfg.Focus,
+		StartedAt:      now,
+		PhaseStartedAt: now,
+		UpdatedAt:      now,
+	}
+	if from != nil {
+		session.StartAt = from.Position()
+		state.Round, state.Phase, state.Remaining = from.Round, from.Phase, from.Remaining
+		state.StartedAt, state.PhaseStartedAt = from.StartedAt, from.PhaseStartedAt
+	}
+
+	// Keep the state file current from the session's ticks
+	ticks := make(chan pomodoro.Tick, 16)
+	session.Ticks = ticks
+	saveFailed := false
+	saveState := func() {
+		if err := pomodoro.SaveState(statePath, state); err != nil && !saveFailed {
+			fmt.Printf("⚠️  %v\n", err)
+			saveFailed = true
+		}
+	}
+	saveState()
+	saved := make(chan struct{})
+	go func() {
+		defer close(saved)
+		lastSave := time.Now()
+		for tick := range ticks {
+			now := time.Now()
+			if state.Update(tick, now) || now.Sub(lastSave) >= pomodoro.StateSaveInterval {
+				saveState()
+				lastSave = now
+			}
+		}
+	}()
+
+	fmt.Println(colorize("p: pause  Enter: resume  s: skip  q: stop", "dim"))
+	go controlPomodoro(session, os.Stdin)
+
+	err = session.Run(ctx)
+	close(ticks)
+	<-saved
+	if errors.Is(err, context.Canceled) {
+		// Interrupted or killed rather than stopped: keep the session for
+		// pomo resume
+		state.Interrupted = true
+		state.UpdatedAt = time.Now()
+		saveState()
+	} else if removeErr := pomodoro.RemoveState(statePath); removeErr != nil {
+		fmt.Printf("⚠️  %v\n", removeErr)
+	}
+	if updated, loadErr := store.GetTask(task.ID); loadErr == nil {
+		if count := len(updated.SessionInterruptions(state.StartedAt)); count > 0 {
+			fmt.Printf("✋ %d interruptions logged this session\n", count)
+		}
+	}
+
+	switch {
+	case errors.Is(err, pomodoro.ErrStopped):
+		fmt.Println("⏹️  Pomodoro stopped")
+	case errors.Is(err, context.Canceled):
+		if saveFailed {
+			fmt.Println("⏹️  Pomodoro interrupted")
+		} else {
+			fmt.Printf("⏹️  Pomodoro interrupted with %s left. Resume it with: qomoboro pomo resume\n", formatDuration(state.Remaining))
+		}
+	default:
+		return err
+	}
+	return nil
+}
+
+// closeStalePomodoro closes out a pomodoro whose process ended without
+// finishing it, e.g. because its terminal was closed: the task is paused
+// as of the last time the session was known to be running, and the session
+// is kept for pomo resume. It runs before every command, so it reports on
+// stderr to keep machine-readable output clean.
+func closeStalePomodoro(store storage.Storage, dataDir string) {
+	statePath := filepath.Join(dataDir, pomodoro.StateFileName)
+	state, err := pomodoro.LoadState(statePath)
+	if errors.Is(err, pomodoro.ErrCorruptState) {
+		// Move it aside so that the warning is not repeated on every run
+		aside := statePath + ".corrupt"
+		if renameErr := os.Rename(statePath, aside); renameErr != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "⚠️  %v; moved it to %s\n", err, aside)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		return
+	}
+	if state == nil || !state.IsStale() {
+		return
+	}
+
+	task, err := store.GetTask(state.TaskID)
+	if err == nil && task.IsActive() {
+		end := state.UpdatedAt
+		if task.StartTime != nil && task.StartTime.After(end) {
+			end = *task.StartTime
+		}
+		task.PauseAt(end)
+		if err := store.UpdateTask(task); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Could not pause %s: %v\n", task.Title, err)
+			return
+		}
+		refreshStats(store, end)
+	}
+
+	state.Interrupted = true
+	if err := pomodoro.SaveState(statePath, state); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "⚠️  The pomodoro on %s was cut short at %s with %s left. Resume it with: qomoboro pomo resume\n",
+		state.TaskTitle, state.UpdatedAt.Local().Format("15:04"), formatDuration(state.Remaining))
+}
+
+// controlPomodoro drives session from commands typed one per line
+func controlPomodoro(session *pomodoro.Session, input io.Reader) {
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "":
+			session.Resume()
+		case "p", "pause":
+			session.Pause()
+			fmt.Println("⏸️  Timer paused, press Enter to resume")
+		case "s", "skip":
+			session.Skip()
+		case "q", "stop":
+			session.Stop()
+		}
+	}
+}
+
+// pomodoroOptions are the settings of a pomo run
+type pomodoroOptions struct {
+	Config pomodoro.Config
+	Rounds int      // Zero runs one full cycle
+	Notify []string // Notifier names
+
+	StartSound pomodoro.Sound
+	StopSound  pomodoro.Sound
+}
+
+// updatePomodoroTask reloads a task, applies change and saves it, so that
+// edits made from other shells during a long pomodoro run are kept. It
+// returns nil after printing a warning when the task could not be updated.
+func updatePomodoroTask(store storage.Storage, id string, change func(*models.Task)) *models.Task {
+	task, err := store.GetTask(id)
+	if err != nil {
+		fmt.Printf("⚠️  Could not load task: %v\n", err)
+		return nil
+	}
+
+	change(task)
+
+	if err := store.UpdateTask(task); err != nil {
+		fmt.Printf("⚠️  Could not update task: %v\n", err)
+		return nil
+	}
+	refreshStats(store)
+	return task
+}
+
+// pomodoroDefaults converts the pomodoro config into pomo run settings.
+// Sounds named start and stop in the sounds folder of the config directory
+// replace the built-in ones, and sounds set in the config replace both.
+func pomodoroDefaults(cfg *config.Config) pomodoroOptions {
+	start, stop := pomodoro.DefaultStartSound, pomodoro.DefaultStopSound
+	if dir, err := config.Dir(appName); err == nil {
+		sounds := filepath.Join(dir, "sounds")
+		start = pomodoro.FindSound(sounds, "start", start)
+		stop = pomodoro.FindSound(sounds, "stop", stop)
+
+		if cfg.Pomodoro.StartSound != "" {
+			start = pomodoro.SoundFile(resolvePath(dir, cfg.Pomodoro.StartSound))
+		}
+		if cfg.Pomodoro.StopSound != "" {
+			stop = pomodoro.SoundFile(resolvePath(dir, cfg.Pomodoro.StopSound))
+		}
+	}
+
+	return pomodoroOptions{
+		Config: pomodoro.Config{
+			Focus:          cfg.Pomodoro.Focus.Duration,
+			ShortBreak:     cfg.Pomodoro.ShortBreak.Duration,
+			LongBreak:      cfg.Pomodoro.LongBreak.Duration,
+			LongBreakEvery: cfg.Pomodoro.LongBreakEvery,
+			AutoStart:      cfg.Pomodoro.AutoStart,
+		},
+		Notify:     cfg.Pomodoro.Notify,
+		StartSound: start,
+		StopSound:  stop,
+	}
+}
+
+// resolvePath makes a relative path relative to dir, expanding ~ to the
+// home directory
+func resolvePath(dir, path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// pomodoroNotifier combines the notifiers named in opts
+func pomodoroNotifier(opts pomodoroOptions) (pomodoro.Notifier, error) {
+	var notifiers pomodoro.MultiNotifier
+	for _, name := range opts.Notify {
+		notifier, err := pomodoro.NotifierByName(strings.TrimSpace(name), opts.StartSound, opts.StopSound)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	return notifiers, nil
+}
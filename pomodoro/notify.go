@@ -0,0 +1,163 @@
+package pomodoro
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// EventKind tells whether a phase began or ran out
+type EventKind int
+
+const (
+	EventPhaseStarted EventKind = iota
+	EventPhaseEnded
+)
+
+// Event is something a Notifier tells the user about
+type Event struct {
+	Kind  EventKind
+	Phase Phase
+	Round int
+	Next  Phase // The phase that follows an ended one
+}
+
+// Message describes the event in a sentence, e.g. "Focus 2 done, time for
+// a short break"
+func (e Event) Message() string {
+	if e.Kind == EventPhaseStarted {
+		return fmt.Sprintf("Starting %s %d", e.Phase, e.Round)
+	}
+	if e.Phase == PhaseFocus {
+		return fmt.Sprintf("Focus %d done, time for a %s", e.Round, e.Next)
+	}
+	return fmt.Sprintf("%s over, back to focus", strings.ToUpper(e.Phase.String()[:1])+e.Phase.String()[1:])
+}
+
+// Notifier lets the user know that a phase started or ended. Notify
+// returns an error instead of giving up on the session when it cannot.
+type Notifier interface {
+	Notify(event Event) error
+}
+
+// Notifier names accepted by NotifierByName
+const (
+	NotifierSound   = "sound"
+	NotifierBell    = "bell"
+	NotifierDesktop = "desktop"
+	NotifierNone    = "none"
+)
+
+// NotifierByName returns the notifier of the given kind; start and stop
+// are only used by the sound notifier
+func NotifierByName(name string, start, stop Sound) (Notifier, error) {
+	switch name {
+	case NotifierSound:
+		return NewSoundNotifier(start, stop), nil
+	case NotifierBell:
+		return NewBellNotifier(os.Stdout), nil
+	case NotifierDesktop:
+		return NewDesktopNotifier(), nil
+	case NotifierNone:
+		return NopNotifier{}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q (want %s, %s, %s or %s)",
+			name, NotifierSound, NotifierBell, NotifierDesktop, NotifierNone)
+	}
+}
+
+// MultiNotifier notifies every notifier in turn, reporting all failures
+type MultiNotifier []Notifier
+
+// Notify implements Notifier
+func (m MultiNotifier) Notify(event Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NopNotifier does nothing, for headless machines
+type NopNotifier struct{}
+
+// Notify implements Notifier
+func (NopNotifier) Notify(Event) error { return nil }
+
+// Recorder keeps every event it is notified of, for tests
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Notify implements Notifier
+func (r *Recorder) Notify(event Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// Events returns the events recorded so far
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// BellNotifier rings the terminal bell when a phase ends
+type BellNotifier struct {
+	out io.Writer
+}
+
+// NewBellNotifier creates a bell notifier writing to out
+func NewBellNotifier(out io.Writer) *BellNotifier {
+	return &BellNotifier{out: out}
+}
+
+// Notify implements Notifier
+func (b *BellNotifier) Notify(event Event) error {
+	if event.Kind != EventPhaseEnded {
+		return nil
+	}
+	if _, err := io.WriteString(b.out, "\a"); err != nil {
+		return fmt.Errorf("failed to ring bell: %w", err)
+	}
+	return nil
+}
+
+// DesktopNotifier shows a desktop notification with notify-send when a
+// phase ends
+type DesktopNotifier struct {
+	Command string // Defaults to notify-send
+}
+
+// NewDesktopNotifier creates a notifier using notify-send
+func NewDesktopNotifier() *DesktopNotifier {
+	return &DesktopNotifier{Command: "notify-send"}
+}
+
+// Notify implements Notifier
+func (d *DesktopNotifier) Notify(event Event) error {
+	if event.Kind != EventPhaseEnded {
+		return nil
+	}
+
+	command := d.Command
+	if command == "" {
+		command = "notify-send"
+	}
+	if out, err := exec.Command(command, "qomoboro", event.Message()).CombinedOutput(); err != nil {
+		if detail := strings.TrimSpace(string(out)); detail != "" {
+			return fmt.Errorf("failed to send desktop notification: %w: %s", err, detail)
+		}
+		return fmt.Errorf("failed to send desktop notification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package pomodoro
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// failingNotifier always fails, like a sound notifier without a speaker
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(Event) error { return errors.New("no speaker") }
+
+func TestSession_NotifiesPhases(t *testing.T) {
+	s, _, _ := newTestSession(DefaultConfig(), 1)
+	recorder := &Recorder{}
+	s.Notifier = recorder
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Session.Run() error = %v", err)
+	}
+
+	want := []Event{
+		{Kind: EventPhaseStarted, Phase: PhaseFocus, Round: 1},
+		{Kind: EventPhaseEnded, Phase: PhaseFocus, Round: 1, Next: PhaseShortBreak},
+		{Kind: EventPhaseStarted, Phase: PhaseShortBreak, Round: 1},
+		{Kind: EventPhaseEnded, Phase: PhaseShortBreak, Round: 1, Next: PhaseFocus},
+	}
+	if got := recorder.Events(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Recorder.Events() = %v, want %v", got, want)
+	}
+}
+
+func TestSession_ReportsNotifierErrors(t *testing.T) {
+	s, _, events := newTestSession(DefaultConfig(), 1)
+	s.Notifier = MultiNotifier{failingNotifier{}, NopNotifier{}}
+
+	var failures []error
+	s.Hooks.NotifyFailed = func(err error) {
+		failures = append(failures, err)
+	}
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Session.Run() error = %v, want the session to finish despite notifier errors", err)
+	}
+	if len(*events) != 2 {
+		t.Errorf("Session.Run() finished %d phases, want 2", len(*events))
+	}
+	if len(failures) != 4 {
+		t.Errorf("NotifyFailed called %d times, want once per notification", len(failures))
+	}
+}
+
+func TestBellNotifier(t *testing.T) {
+	var out bytes.Buffer
+	bell := NewBellNotifier(&out)
+
+	bell.Notify(Event{Kind: EventPhaseStarted, Phase: PhaseFocus, Round: 1})
+	bell.Notify(Event{Kind: EventPhaseEnded, Phase: PhaseFocus, Round: 1, Next: PhaseShortBreak})
+
+	if got := out.String(); got != "\a" {
+		t.Errorf("BellNotifier wrote %q, want a single bell when the phase ends", got)
+	}
+}
+
+func TestDesktopNotifier_MissingCommand(t *testing.T) {
+	desktop := &DesktopNotifier{Command: filepath.Join(t.TempDir(), "notify-send")}
+
+	if err := desktop.Notify(Event{Kind: EventPhaseEnded, Phase: PhaseFocus, Round: 1}); err == nil {
+		t.Errorf("DesktopNotifier.Notify() error = nil, want error for missing command")
+	}
+}
+
+func TestSoundNotifier_MissingFile(t *testing.T) {
+	sound := NewSoundNotifier(SoundFile(filepath.Join(t.TempDir(), "missing.wav")), Sound{})
+
+	if err := sound.Notify(Event{Kind: EventPhaseStarted, Phase: PhaseFocus, Round: 1}); err == nil {
+		t.Errorf("SoundNotifier.Notify() error = nil, want error for missing file")
+	}
+	if err := sound.Notify(Event{Kind: EventPhaseEnded, Phase: PhaseFocus, Round: 1}); err != nil {
+		t.Errorf("SoundNotifier.Notify() without stop sound error = %v, want nil", err)
+	}
+}
+
+func TestNotifierByName(t *testing.T) {
+	for _, name := range []string{NotifierSound, NotifierBell, NotifierDesktop, NotifierNone} {
+		if _, err := NotifierByName(name, DefaultStartSound, DefaultStopSound); err != nil {
+			t.Errorf("NotifierByName(%q) error = %v", name, err)
+		}
+	}
+	if _, err := NotifierByName("pager", DefaultStartSound, DefaultStopSound); err == nil {
+		t.Errorf("NotifierByName(%q) error = nil, want error", "pager")
+	}
+}
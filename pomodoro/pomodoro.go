@@ -1,77 +1,408 @@
 package pomodoro
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"os"
+	"sync"
 	"time"
+)
+
+// Phase is one stretch of a pomodoro cycle
+type Phase int
 
-	"github.com/faiface/beep"
-	"github.com/faiface/beep/speaker"
-	"github.com/faiface/beep/wav"
+const (
+	PhaseFocus Phase = iota
+	PhaseShortBreak
+	PhaseLongBreak
 )
 
-type pomodoro struct {
-	Type       string
-	Duration   time.Duration
-	StartSound string
-	StopSound  string
+// String returns the string representation of Phase
+func (p Phase) String() string {
+	switch p {
+	case PhaseFocus:
+		return "focus"
+	case PhaseShortBreak:
+		return "short break"
+	case PhaseLongBreak:
+		return "long break"
+	default:
+		return "unknown"
+	}
+}
+
+// IsBreak returns true for both short and long breaks
+func (p Phase) IsBreak() bool {
+	return p == PhaseShortBreak || p == PhaseLongBreak
+}
+
+// Config describes a pomodoro cycle: LongBreakEvery focus phases separated
+// by short breaks, followed by a long break
+type Config struct {
+	Focus          time.Duration `json:"focus"`
+	ShortBreak     time.Duration `json:"short_break"`
+	LongBreak      time.Duration `json:"long_break"`
+	LongBreakEvery int           `json:"long_break_every"`
+	AutoStart      bool          `json:"auto_start"` // Start the next phase without waiting for Enter
+}
+
+// DefaultConfig returns the classic 25/5/15 pattern with a long break after
+// every fourth focus phase
+func DefaultConfig() Config {
+	return Config{
+		Focus:          25 * time.Minute,
+		ShortBreak:     5 * time.Minute,
+		LongBreak:      15 * time.Minute,
+		LongBreakEvery: 4,
+		AutoStart:      true,
+	}
+}
+
+// Validate checks that every phase has a length and cycles are not empty
+func (c Config) Validate() error {
+	if c.Focus <= 0 || c.ShortBreak <= 0 || c.LongBreak <= 0 {
+		return fmt.Errorf("focus, short break and long break must be longer than zero")
+	}
+	if c.LongBreakEvery < 1 {
+		return fmt.Errorf("long break interval must be at least 1 focus phase")
+	}
+	return nil
+}
+
+// BreakAfter returns the break that follows the given focus round,
+// counting from 1
+func (c Config) BreakAfter(round int) Phase {
+	if c.LongBreakEvery > 0 && round%c.LongBreakEvery == 0 {
+		return PhaseLongBreak
+	}
+	return PhaseShortBreak
+}
+
+// Duration returns how long phase lasts
+func (c Config) Duration(phase Phase) time.Duration {
+	switch phase {
+	case PhaseShortBreak:
+		return c.ShortBreak
+	case PhaseLongBreak:
+		return c.LongBreak
+	default:
+		return c.Focus
+	}
 }
 
-func New(pomoType string, pomoDuration int, startSoundPath string, stopSoundPath string) pomodoro {
+// Hooks are called as a session moves between phases. Round counts focus
+// phases from 1; a break shares the round of the focus phase before it.
+// Nil hooks are skipped. The package prints nothing itself, so callers
+// report progress through hooks and ticks in their own way.
+type Hooks struct {
+	PhaseReady   func(phase Phase, round int) // Waiting for Resume because AutoStart is off; Skip passes over the phase
+	PhaseStarted func(phase Phase, round int)
+	PhaseEnded   func(phase Phase, round int, completed bool) // completed is false when skipped, stopped or cancelled
+	Finished     func()                                       // After the last break, when Run returns nil
+	NotifyFailed func(err error)                              // Defaults to a warning on stderr
+}
 
-	duration := time.Duration(pomoDuration) * time.Second
-	p := pomodoro{pomoType, duration, startSoundPath, stopSoundPath}
+// Tick reports the state of the running phase, sent about once per
+// TickInterval and whenever the session is paused
+type Tick struct {
+	Phase     Phase
+	Round     int
+	Rounds    int
+	Duration  time.Duration
+	Remaining time.Duration
+	Paused    bool
+}
 
-	return p
+// Position is a point within a session: a phase of a round, with the time
+// left in it
+type Position struct {
+	Phase     Phase
+	Round     int
+	Remaining time.Duration // Zero means the whole phase
 }
 
-func (p pomodoro) Repeat(repetitions int) {
-	numPomodoros := repetitions
-	breakPomo := New("break", 1, "assets/sounds/start_beep.wav", "assets/sounds/stop_beep.wav")
+// Clock tells the time and waits; sessions take one so that tests can run
+// whole cycles without sleeping
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock backed by the time package
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// ErrStopped is returned by Run when the session was stopped with Stop
+var ErrStopped = errors.New("pomodoro session stopped")
+
+// Session runs a number of focus rounds, each followed by a short or long
+// break as configured. Pause, Resume, Skip and Stop may be called from any
+// goroutine while Run is in progress.
+type Session struct {
+	Config       Config
+	Rounds       int      // Focus rounds to run; zero runs one full cycle
+	StartAt      Position // Where to begin; the zero Position is the first focus phase
+	Notifier     Notifier // Defaults to NopNotifier
+	Hooks        Hooks
+	Ticks        chan<- Tick   // Optional; ticks are dropped when the receiver is not ready
+	Clock        Clock         // Defaults to the system clock
+	TickInterval time.Duration // Defaults to one second
 
-	for i := 0; i < numPomodoros; i++ {
-		executePomodoro(p)
-		executePomodoro(breakPomo)
+	mu     sync.Mutex
+	paused bool
+	skip   bool
+	stop   bool
+	wake   chan struct{}
+}
+
+// NewSession creates a session for cfg that plays the default sounds
+func NewSession(cfg Config, rounds int) *Session {
+	return &Session{
+		Config:   cfg,
+		Rounds:   rounds,
+		Notifier: NewSoundNotifier(DefaultStartSound, DefaultStopSound),
 	}
+}
 
-	fmt.Println("All done! Good job!")
+// Pause holds the countdown of the current phase
+func (s *Session) Pause() {
+	s.control(func() { s.paused = true })
 }
 
-func executePomodoro(p pomodoro) {
-	playSound(p.StartSound)
-	fmt.Printf("Starting %s session...\n", p.Type)
-	startTimer(time.Duration(p.Duration), p.Type)
-	playSound(p.StopSound)
+// Resume continues a paused phase, or starts a phase that is waiting
+// because AutoStart is off
+func (s *Session) Resume() {
+	s.control(func() { s.paused = false })
 }
 
-func startTimer(duration time.Duration, label string) {
-	timer := time.NewTimer(duration)
+// Skip ends the current phase early and moves on to the next
+func (s *Session) Skip() {
+	s.control(func() {
+		s.skip = true
+		s.paused = false
+	})
+}
 
-	<-timer.C
-	fmt.Printf("Ending %s session: Time's up!\n", label)
+// Stop ends the session; Run returns ErrStopped
+func (s *Session) Stop() {
+	s.control(func() { s.stop = true })
 }
 
-func playSound(soundFile string) {
-	f, err := os.Open(soundFile)
-	if err != nil {
-		panic(err)
+// control changes the session state and wakes the running phase
+func (s *Session) control(change func()) {
+	s.mu.Lock()
+	change()
+	if s.wake == nil {
+		s.wake = make(chan struct{}, 1)
 	}
+	wake := s.wake
+	s.mu.Unlock()
 
-	s, format, err := wav.Decode(f)
-	if err != nil {
-		log.Fatal(err)
+	select {
+	case wake <- struct{}{}:
+	default:
 	}
-	defer s.Close()
+}
 
-	err = speaker.Init(format.SampleRate, format.SampleRate.N(time.Second/10))
-	if err != nil {
-		panic(err)
+// state returns whether the session is paused or stopped, and whether a
+// skip was requested since the last call
+func (s *Session) state() (paused, skip, stop bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	skip, s.skip = s.skip, false
+	return s.paused, skip, s.stop
+}
+
+// Run plays the session through to the last break. It returns ctx.Err()
+// when ctx is cancelled and ErrStopped when the session is stopped.
+func (s *Session) Run(ctx context.Context) error {
+	if err := s.Config.Validate(); err != nil {
+		return err
 	}
 
-	done := make(chan bool)
-	speaker.Play(beep.Seq(s, beep.Callback(func() {
-		done <- true
-	})))
-	<-done
+	s.mu.Lock()
+	if s.wake == nil {
+		s.wake = make(chan struct{}, 1)
+	}
+	s.mu.Unlock()
+	if s.Clock == nil {
+		s.Clock = systemClock{}
+	}
+	if s.TickInterval <= 0 {
+		s.TickInterval = time.Second
+	}
+	if s.Notifier == nil {
+		s.Notifier = NopNotifier{}
+	}
+
+	rounds := s.Rounds
+	if rounds <= 0 {
+		rounds = s.Config.LongBreakEvery
+	}
+
+	start := s.StartAt
+	if start.Round < 1 {
+		start = Position{Phase: PhaseFocus, Round: 1}
+	}
+
+	first := true
+	for round := start.Round; round <= rounds; round++ {
+		for _, phase := range []Phase{PhaseFocus, s.Config.BreakAfter(round)} {
+			if first && start.Phase.IsBreak() && !phase.IsBreak() {
+				continue
+			}
+			remaining := s.Config.Duration(phase)
+			if first && start.Remaining > 0 && start.Remaining < remaining {
+				remaining = start.Remaining
+			}
+			if err := s.runPhase(ctx, phase, round, rounds, remaining, first); err != nil {
+				return err
+			}
+			first = false
+		}
+	}
+
+	if s.Hooks.Finished != nil {
+		s.Hooks.Finished()
+	}
+	return nil
+}
+
+// runPhase counts down remaining of one phase, waiting first for Resume
+// unless phases start automatically. The first phase of a run always starts
+// right away.
+func (s *Session) runPhase(ctx context.Context, phase Phase, round, rounds int, remaining time.Duration, first bool) error {
+	tick := Tick{Phase: phase, Round: round, Rounds: rounds, Duration: s.Config.Duration(phase), Remaining: remaining}
+
+	if !s.Config.AutoStart && !first {
+		s.control(func() { s.paused = true })
+		if s.Hooks.PhaseReady != nil {
+			s.Hooks.PhaseReady(phase, round)
+		}
+		ready := tick
+		ready.Paused = true
+		if err := s.waitForResume(ctx, ready); err != nil {
+			return err
+		}
+		// A phase skipped or stopped before it started never starts
+		_, skip, stop := s.state()
+		if stop {
+			return ErrStopped
+		}
+		if skip {
+			return nil
+		}
+	}
+
+	if s.Hooks.PhaseStarted != nil {
+		s.Hooks.PhaseStarted(phase, round)
+	}
+	end := func(completed bool) {
+		if s.Hooks.PhaseEnded != nil {
+			s.Hooks.PhaseEnded(phase, round, completed)
+		}
+	}
+
+	s.notify(Event{Kind: EventPhaseStarted, Phase: phase, Round: round})
+	s.sendTick(tick)
+
+	last := s.Clock.Now()
+	for tick.Remaining > 0 {
+		paused, skip, stop := s.state()
+		switch {
+		case stop:
+			end(false)
+			return ErrStopped
+		case skip:
+			end(false)
+			return nil
+		case paused:
+			tick.Paused = true
+			if err := s.waitForResume(ctx, tick); err != nil {
+				end(false)
+				return err
+			}
+			tick.Paused = false
+			last = s.Clock.Now()
+			s.sendTick(tick)
+			continue
+		}
+
+		wait := s.TickInterval
+		if tick.Remaining < wait {
+			wait = tick.Remaining
+		}
+
+		select {
+		case <-ctx.Done():
+			end(false)
+			return ctx.Err()
+		case <-s.wake:
+			// Count the time up to the state change
+			now := s.Clock.Now()
+			tick.Remaining -= now.Sub(last)
+			last = now
+		case now := <-s.Clock.After(wait):
+			tick.Remaining -= now.Sub(last)
+			last = now
+			if tick.Remaining < 0 {
+				tick.Remaining = 0
+			}
+			s.sendTick(tick)
+		}
+	}
+
+	next := PhaseFocus
+	if phase == PhaseFocus {
+		next = s.Config.BreakAfter(round)
+	}
+	s.notify(Event{Kind: EventPhaseEnded, Phase: phase, Round: round, Next: next})
+	end(true)
+	return nil
+}
+
+// waitForResume blocks while the session is paused, returning early when it
+// is stopped or skipped, which the caller then sees in state
+func (s *Session) waitForResume(ctx context.Context, tick Tick) error {
+	s.sendTick(tick)
+	for {
+		s.mu.Lock()
+		waiting := s.paused && !s.skip && !s.stop
+		s.mu.Unlock()
+		if !waiting {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.wake:
+		}
+	}
+}
+
+// notify passes event to the notifier, reporting rather than failing on
+// errors so that a missing speaker never ends a session
+func (s *Session) notify(event Event) {
+	if err := s.Notifier.Notify(event); err != nil {
+		if s.Hooks.NotifyFailed != nil {
+			s.Hooks.NotifyFailed(err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Warning: notification failed: %v\n", err)
+	}
+}
+
+// sendTick delivers tick without blocking the countdown
+func (s *Session) sendTick(tick Tick) {
+	if s.Ticks == nil {
+		return
+	}
+	select {
+	case s.Ticks <- tick:
+	default:
+	}
 }
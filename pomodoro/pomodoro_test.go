@@ -0,0 +1,283 @@
+package pomodoro
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConfig_BreakAfter(t *testing.T) {
+	cfg := DefaultConfig()
+
+	tests := []struct {
+		round int
+		want  Phase
+	}{
+		{1, PhaseShortBreak},
+		{3, PhaseShortBreak},
+		{4, PhaseLongBreak},
+		{5, PhaseShortBreak},
+		{8, PhaseLongBreak},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.BreakAfter(tt.round); got != tt.want {
+			t.Errorf("Config.BreakAfter(%d) = %v, want %v", tt.round, got, tt.want)
+		}
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{"default", func(c *Config) {}, false},
+		{"zero focus", func(c *Config) { c.Focus = 0 }, true},
+		{"negative long break", func(c *Config) { c.LongBreak = -time.Minute }, true},
+		{"no focus phases per cycle", func(c *Config) { c.LongBreakEvery = 0 }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(&cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeClock jumps forward by the requested wait instead of sleeping
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// phaseEvent records one PhaseEnded call
+type phaseEvent struct {
+	phase     Phase
+	round     int
+	completed bool
+}
+
+// newTestSession returns a silent session on a fake clock that records the
+// phases it finishes
+func newTestSession(cfg Config, rounds int) (*Session, *fakeClock, *[]phaseEvent) {
+	clock := &fakeClock{now: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)}
+	events := &[]phaseEvent{}
+
+	s := NewSession(cfg, rounds)
+	s.Notifier = NopNotifier{}
+	s.Clock = clock
+	s.Hooks.PhaseEnded = func(phase Phase, round int, completed bool) {
+		*events = append(*events, phaseEvent{phase, round, completed})
+	}
+	return s, clock, events
+}
+
+func TestSession_RunFullCycle(t *testing.T) {
+	s, clock, events := newTestSession(DefaultConfig(), 0)
+	start := clock.Now()
+	finished := 0
+	s.Hooks.Finished = func() { finished++ }
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Session.Run() error = %v", err)
+	}
+	if finished != 1 {
+		t.Errorf("Session.Run() called Finished %d times, want once", finished)
+	}
+
+	want := []phaseEvent{
+		{PhaseFocus, 1, true}, {PhaseShortBreak, 1, true},
+		{PhaseFocus, 2, true}, {PhaseShortBreak, 2, true},
+		{PhaseFocus, 3, true}, {PhaseShortBreak, 3, true},
+		{PhaseFocus, 4, true}, {PhaseLongBreak, 4, true},
+	}
+	if !reflect.DeepEqual(*events, want) {
+		t.Errorf("Session.Run() phases = %v, want %v", *events, want)
+	}
+
+	if got, want := clock.Now().Sub(start), 4*25*time.Minute+3*5*time.Minute+15*time.Minute; got != want {
+		t.Errorf("Session.Run() took %v, want %v", got, want)
+	}
+}
+
+func TestSession_SkipAndStop(t *testing.T) {
+	s, clock, events := newTestSession(DefaultConfig(), 3)
+	start := clock.Now()
+	s.Hooks.PhaseStarted = func(phase Phase, round int) {
+		switch {
+		case phase == PhaseFocus && round == 1:
+			s.Skip()
+		case phase == PhaseFocus && round == 2:
+			s.Stop()
+		}
+	}
+
+	if err := s.Run(context.Background()); err != ErrStopped {
+		t.Errorf("Session.Run() error = %v, want %v", err, ErrStopped)
+	}
+
+	want := []phaseEvent{
+		{PhaseFocus, 1, false}, {PhaseShortBreak, 1, true},
+		{PhaseFocus, 2, false},
+	}
+	if !reflect.DeepEqual(*events, want) {
+		t.Errorf("Session.Run() phases = %v, want %v", *events, want)
+	}
+	if got := clock.Now().Sub(start); got != 5*time.Minute {
+		t.Errorf("Session.Run() took %v, want only the short break", got)
+	}
+}
+
+func TestSession_Cancel(t *testing.T) {
+	s, _, events := newTestSession(DefaultConfig(), 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	// Hold the phase so that only cancellation can end it
+	s.Hooks.PhaseStarted = func(phase Phase, round int) {
+		if round == 2 {
+			s.Pause()
+			cancel()
+		}
+	}
+
+	if err := s.Run(ctx); err != context.Canceled {
+		t.Errorf("Session.Run() error = %v, want %v", err, context.Canceled)
+	}
+	if last := (*events)[len(*events)-1]; last != (phaseEvent{PhaseFocus, 2, false}) {
+		t.Errorf("last phase = %v, want unfinished second focus", last)
+	}
+}
+
+func TestSession_PauseResume(t *testing.T) {
+	s, clock, _ := newTestSession(DefaultConfig(), 1)
+	start := clock.Now()
+	ticks := make(chan Tick, 16)
+	s.Ticks = ticks
+
+	s.Hooks.PhaseStarted = func(phase Phase, round int) {
+		if phase == PhaseFocus {
+			s.Pause()
+			go func() {
+				for tick := range ticks {
+					if tick.Paused {
+						s.Resume()
+						return
+					}
+				}
+			}()
+		}
+	}
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Session.Run() error = %v", err)
+	}
+	if got, want := clock.Now().Sub(start), 25*time.Minute+5*time.Minute; got != want {
+		t.Errorf("Session.Run() took %v, want %v", got, want)
+	}
+}
+
+func TestSession_StartAt(t *testing.T) {
+	s, clock, events := newTestSession(DefaultConfig(), 3)
+	start := clock.Now()
+	s.StartAt = Position{Phase: PhaseShortBreak, Round: 2, Remaining: time.Minute}
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Session.Run() error = %v", err)
+	}
+
+	want := []phaseEvent{
+		{PhaseShortBreak, 2, true},
+		{PhaseFocus, 3, true}, {PhaseShortBreak, 3, true},
+	}
+	if !reflect.DeepEqual(*events, want) {
+		t.Errorf("Session.Run() phases = %v, want %v", *events, want)
+	}
+	if got, want := clock.Now().Sub(start), time.Minute+25*time.Minute+5*time.Minute; got != want {
+		t.Errorf("Session.Run() took %v, want %v", got, want)
+	}
+}
+
+func TestSession_WaitsWithoutAutoStart(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AutoStart = false
+	s, _, events := newTestSession(cfg, 2)
+
+	var ready []Phase
+	s.Hooks.PhaseReady = func(phase Phase, round int) {
+		ready = append(ready, phase)
+		s.Resume()
+	}
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Session.Run() error = %v", err)
+	}
+
+	want := []Phase{PhaseShortBreak, PhaseFocus, PhaseShortBreak}
+	if !reflect.DeepEqual(ready, want) {
+		t.Errorf("phases waiting for Resume = %v, want %v", ready, want)
+	}
+	if len(*events) != 4 {
+		t.Errorf("Session.Run() finished %d phases, want 4", len(*events))
+	}
+}
+
+func TestSession_SkipWhileWaiting(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AutoStart = false
+	s, clock, events := newTestSession(cfg, 1)
+	start := clock.Now()
+	ticks := make(chan Tick, 16)
+	s.Ticks = ticks
+	s.TickInterval = time.Hour // One tick per phase, so none are dropped
+
+	var started []Phase
+	s.Hooks.PhaseStarted = func(phase Phase, round int) { started = append(started, phase) }
+	s.Hooks.PhaseReady = func(phase Phase, round int) { s.Skip() }
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Session.Run() error = %v", err)
+	}
+
+	if want := []Phase{PhaseFocus}; !reflect.DeepEqual(started, want) {
+		t.Errorf("started phases = %v, want %v", started, want)
+	}
+	if want := []phaseEvent{{PhaseFocus, 1, true}}; !reflect.DeepEqual(*events, want) {
+		t.Errorf("Session.Run() phases = %v, want %v", *events, want)
+	}
+	if got := clock.Now().Sub(start); got != 25*time.Minute {
+		t.Errorf("Session.Run() took %v, want only the focus phase", got)
+	}
+
+	close(ticks)
+	var waiting *Tick
+	for tick := range ticks {
+		if tick.Phase == PhaseShortBreak {
+			waiting = &tick
+		}
+	}
+	if waiting == nil || !waiting.Paused {
+		t.Errorf("tick of the waiting break = %+v, want paused", waiting)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package pomodoro
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive reports whether a process with the given ID is running
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
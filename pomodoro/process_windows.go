@@ -0,0 +1,26 @@
+//go:build windows
+
+package pomodoro
+
+import "golang.org/x/sys/windows"
+
+// stillActive is the exit code of a process that has not exited
+const stillActive = 259
+
+// processAlive reports whether a process with the given ID is running
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(handle)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
@@ -0,0 +1,165 @@
+package pomodoro
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/mp3"
+	"github.com/faiface/beep/speaker"
+	"github.com/faiface/beep/vorbis"
+	"github.com/faiface/beep/wav"
+
+	"qomoboro/assets"
+)
+
+// SoundFormats lists the file extensions sounds can be decoded from
+var SoundFormats = []string{".wav", ".ogg", ".mp3"}
+
+// Sound is an audio clip in one of SoundFormats. The zero Sound is silent.
+type Sound struct {
+	Name string // File name or path; its extension picks the decoder
+	open func() (io.ReadCloser, error)
+}
+
+// SoundFile returns the sound stored at path
+func SoundFile(path string) Sound {
+	return Sound{
+		Name: path,
+		open: func() (io.ReadCloser, error) { return os.Open(path) },
+	}
+}
+
+// embeddedSound returns a sound built into the binary
+func embeddedSound(name string) Sound {
+	return Sound{
+		Name: name,
+		open: func() (io.ReadCloser, error) { return assets.Sounds.Open("sounds/" + name) },
+	}
+}
+
+// Default sounds played at the start and end of every phase
+var (
+	DefaultStartSound = embeddedSound("start_beep.wav")
+	DefaultStopSound  = embeddedSound("stop_beep.wav")
+)
+
+// IsZero reports whether the sound is silent
+func (s Sound) IsZero() bool {
+	return s.open == nil
+}
+
+// FindSound looks in dir for a file called name with one of SoundFormats,
+// e.g. start.ogg for "start", and returns fallback when there is none
+func FindSound(dir, name string, fallback Sound) Sound {
+	for _, ext := range SoundFormats {
+		path := filepath.Join(dir, name+ext)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return SoundFile(path)
+		}
+	}
+	return fallback
+}
+
+// SoundNotifier plays one sound when a phase starts and another when it
+// ends. The speaker is initialized on first use and shared afterwards.
+type SoundNotifier struct {
+	StartSound Sound
+	StopSound  Sound
+}
+
+// NewSoundNotifier creates a notifier playing the given sounds
+func NewSoundNotifier(start, stop Sound) *SoundNotifier {
+	return &SoundNotifier{StartSound: start, StopSound: stop}
+}
+
+// Notify implements Notifier
+func (s *SoundNotifier) Notify(event Event) error {
+	if event.Kind == EventPhaseStarted {
+		return playSound(s.StartSound)
+	}
+	return playSound(s.StopSound)
+}
+
+// The speaker can only be initialized once per process; later sounds are
+// resampled to its rate
+var (
+	speakerOnce sync.Once
+	speakerRate beep.SampleRate
+	speakerErr  error
+)
+
+// initSpeaker initializes the speaker at rate the first time it is called
+func initSpeaker(rate beep.SampleRate) (beep.SampleRate, error) {
+	speakerOnce.Do(func() {
+		speakerRate = rate
+		speakerErr = speaker.Init(rate, rate.N(time.Second/10))
+	})
+	return speakerRate, speakerErr
+}
+
+// decodeSound decodes the sound by the extension of its name
+func decodeSound(sound Sound) (beep.StreamSeekCloser, beep.Format, error) {
+	f, err := sound.open()
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("failed to open sound: %w", err)
+	}
+
+	var (
+		s      beep.StreamSeekCloser
+		format beep.Format
+	)
+	switch ext := strings.ToLower(filepath.Ext(sound.Name)); ext {
+	case ".wav":
+		s, format, err = wav.Decode(f)
+	case ".ogg":
+		s, format, err = vorbis.Decode(f)
+	case ".mp3":
+		s, format, err = mp3.Decode(f)
+	default:
+		f.Close()
+		return nil, beep.Format{}, fmt.Errorf("unsupported sound format %q for %s (want %s)",
+			ext, sound.Name, strings.Join(SoundFormats, ", "))
+	}
+	if err != nil {
+		f.Close()
+		return nil, beep.Format{}, fmt.Errorf("failed to decode sound %s: %w", sound.Name, err)
+	}
+
+	return s, format, nil
+}
+
+// playSound plays a sound and waits for it to finish
+func playSound(sound Sound) error {
+	if sound.IsZero() {
+		return nil
+	}
+
+	s, format, err := decodeSound(sound)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	rate, err := initSpeaker(format.SampleRate)
+	if err != nil {
+		return err
+	}
+
+	var streamer beep.Streamer = s
+	if format.SampleRate != rate {
+		streamer = beep.Resample(4, format.SampleRate, rate, s)
+	}
+
+	done := make(chan struct{})
+	speaker.Play(beep.Seq(streamer, beep.Callback(func() {
+		close(done)
+	})))
+	<-done
+	return nil
+}
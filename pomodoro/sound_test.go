@@ -0,0 +1,51 @@
+package pomodoro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeSound_Embedded(t *testing.T) {
+	for _, sound := range []Sound{DefaultStartSound, DefaultStopSound} {
+		s, format, err := decodeSound(sound)
+		if err != nil {
+			t.Errorf("decodeSound(%s) error = %v", sound.Name, err)
+			continue
+		}
+		if format.SampleRate == 0 || s.Len() == 0 {
+			t.Errorf("decodeSound(%s) = %d samples at %d Hz, want audio", sound.Name, s.Len(), format.SampleRate)
+		}
+		s.Close()
+	}
+}
+
+func TestDecodeSound_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"beep.flac": "fLaC",
+		"beep.wav":  "not a wav file",
+		"beep.ogg":  "not an ogg file",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := decodeSound(SoundFile(filepath.Join(dir, name))); err == nil {
+			t.Errorf("decodeSound(%s) error = nil, want error", name)
+		}
+	}
+}
+
+func TestFindSound(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "stop.ogg"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := FindSound(dir, "stop", DefaultStopSound); got.Name != filepath.Join(dir, "stop.ogg") {
+		t.Errorf("FindSound(stop) = %s, want the override in the config directory", got.Name)
+	}
+	if got := FindSound(dir, "start", DefaultStartSound); got.Name != DefaultStartSound.Name {
+		t.Errorf("FindSound(start) = %s, want the embedded default", got.Name)
+	}
+}
@@ -0,0 +1,135 @@
+package pomodoro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StateFileName is the file in the data directory holding the running
+// session
+const StateFileName = "pomodoro.json"
+
+// StateSaveInterval is how often a running session refreshes its state
+// file, which bounds the time lost when its process dies
+const StateSaveInterval = 15 * time.Second
+
+// ErrCorruptState is wrapped by the error of LoadState when the state file
+// cannot be parsed
+var ErrCorruptState = errors.New("failed to parse pomodoro state")
+
+// State is the persisted snapshot of a session, so that other processes
+// can report on it and a session cut short can be resumed
+type State struct {
+	PID            int           `json:"pid"`
+	TaskID         string        `json:"task_id"`
+	TaskTitle      string        `json:"task_title"`
+	Config         Config        `json:"config"`
+	Rounds         int           `json:"rounds"`
+	Round          int           `json:"round"`
+	Phase          Phase         `json:"phase"`
+	StartedAt      time.Time     `json:"started_at"`
+	PhaseStartedAt time.Time     `json:"phase_started_at"`
+	Remaining      time.Duration `json:"remaining"` // Left in the phase as of UpdatedAt
+	Paused         bool          `json:"paused"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+
+	// Set once the session's process has ended without finishing it
+	Interrupted bool `json:"interrupted,omitempty"`
+}
+
+// Update records tick as the session's progress at now. It reports whether
+// the phase, round or paused state changed, which is worth saving at once.
+func (s *State) Update(tick Tick, now time.Time) bool {
+	changed := tick.Phase != s.Phase || tick.Round != s.Round || tick.Paused != s.Paused
+	if tick.Phase != s.Phase || tick.Round != s.Round || s.PhaseStartedAt.IsZero() {
+		s.PhaseStartedAt = now
+	}
+
+	s.Phase = tick.Phase
+	s.Round = tick.Round
+	s.Rounds = tick.Rounds
+	s.Remaining = tick.Remaining
+	s.Paused = tick.Paused
+	s.UpdatedAt = now
+	return changed
+}
+
+// RemainingAt estimates the time left in the phase at now
+func (s *State) RemainingAt(now time.Time) time.Duration {
+	if s.Paused || s.Interrupted {
+		return s.Remaining
+	}
+	remaining := s.Remaining - now.Sub(s.UpdatedAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Position returns where a resumed session should pick up
+func (s *State) Position() Position {
+	return Position{Phase: s.Phase, Round: s.Round, Remaining: s.Remaining}
+}
+
+// IsStale reports whether the session's process has ended without
+// finishing or interrupting it
+func (s *State) IsStale() bool {
+	return !s.Interrupted && !processAlive(s.PID)
+}
+
+// LoadState reads the state file at path, returning nil when there is none
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read pomodoro state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorruptState, err)
+	}
+	return &state, nil
+}
+
+// SaveState writes state to path, replacing it atomically so readers never
+// see a partial file
+func SaveState(path string, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pomodoro state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write pomodoro state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write pomodoro state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save pomodoro state: %w", err)
+	}
+	return nil
+}
+
+// RemoveState deletes the state file at path; a missing file is not an
+// error
+func RemoveState(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove pomodoro state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package pomodoro
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestState_UpdateAndRemaining(t *testing.T) {
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	state := &State{Phase: PhaseFocus, Round: 1, Remaining: 25 * time.Minute, PhaseStartedAt: start, UpdatedAt: start}
+
+	tick := Tick{Phase: PhaseFocus, Round: 1, Rounds: 4, Remaining: 20 * time.Minute}
+	if changed := state.Update(tick, start.Add(5*time.Minute)); changed {
+		t.Errorf("State.Update() with the same phase = true, want false")
+	}
+	if got := state.RemainingAt(start.Add(8 * time.Minute)); got != 17*time.Minute {
+		t.Errorf("State.RemainingAt() = %v, want 17m", got)
+	}
+
+	tick.Paused = true
+	if changed := state.Update(tick, start.Add(6*time.Minute)); !changed {
+		t.Errorf("State.Update() after pausing = false, want true")
+	}
+	if got := state.RemainingAt(start.Add(time.Hour)); got != 20*time.Minute {
+		t.Errorf("State.RemainingAt() while paused = %v, want 20m", got)
+	}
+
+	tick = Tick{Phase: PhaseShortBreak, Round: 1, Rounds: 4, Remaining: 5 * time.Minute}
+	if changed := state.Update(tick, start.Add(30*time.Minute)); !changed {
+		t.Errorf("State.Update() on a new phase = false, want true")
+	}
+	if !state.PhaseStartedAt.Equal(start.Add(30 * time.Minute)) {
+		t.Errorf("State.PhaseStartedAt = %v, want the time of the first tick of the break", state.PhaseStartedAt)
+	}
+}
+
+func TestSaveLoadState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFileName)
+
+	if state, err := LoadState(path); err != nil || state != nil {
+		t.Fatalf("LoadState() without file = %v, %v, want nil, nil", state, err)
+	}
+
+	want := &State{
+		PID:       os.Getpid(),
+		TaskID:    "task_a",
+		TaskTitle: "Fix bug",
+		Config:    DefaultConfig(),
+		Rounds:    4,
+		Round:     2,
+		Phase:     PhaseShortBreak,
+		Remaining: 3 * time.Minute,
+		StartedAt: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 3, 4, 9, 52, 0, 0, time.UTC),
+	}
+	if err := SaveState(path, want); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	got, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadState() = %+v, want %+v", got, want)
+	}
+
+	if err := RemoveState(path); err != nil {
+		t.Fatalf("RemoveState() error = %v", err)
+	}
+	if err := RemoveState(path); err != nil {
+		t.Errorf("RemoveState() without file error = %v, want nil", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"pid":`), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := LoadState(path); !errors.Is(err, ErrCorruptState) {
+		t.Errorf("LoadState() of a cut short file error = %v, want %v", err, ErrCorruptState)
+	}
+}
+
+func TestState_IsStale(t *testing.T) {
+	// The ID of a process that has exited
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("failed to run helper process: %v", err)
+	}
+	deadPID := cmd.Process.Pid
+
+	tests := []struct {
+		name  string
+		state State
+		want  bool
+	}{
+		{"running in this process", State{PID: os.Getpid()}, false},
+		{"process exited", State{PID: deadPID}, true},
+		{"already closed out", State{PID: deadPID, Interrupted: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.state.IsStale(); got != tt.want {
+				t.Errorf("State.IsStale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -8,8 +8,18 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"qomoboro/internal/cli"
+	"qomoboro/internal/config"
 	"qomoboro/internal/models"
+	"qomoboro/internal/output"
+	"qomoboro/internal/query"
+	"qomoboro/internal/stats"
 	"qomoboro/internal/storage"
+	"qomoboro/internal/ui"
+	"qomoboro/pomodoro"
 )
 
 const (
@@ -26,141 +36,553 @@ const (
 `
 )
 
+// noColor turns off colored output, from --no-color or the NO_COLOR
+// environment variable
+var noColor = os.Getenv("NO_COLOR") != ""
+
 func main() {
+	os.Exit(run())
+}
+
+// run executes the command line and returns the exit code, closing the
+// storage on the way out
+func run() int {
 	// Get data directory
 	dataDir, err := getDataDir()
 	if err != nil {
-		fmt.Printf("Error getting data directory: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "❌ failed to get data directory: %v\n", err)
+		return 1
 	}
 
-	// Initialize storage
-	store, err := storage.NewFileStorage(dataDir)
+	// Load configuration
+	cfg, err := loadConfig()
 	if err != nil {
-		fmt.Printf("Error initializing storage: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "❌ failed to load config: %v\n", err)
+		return 1
 	}
-	defer store.Close()
 
-	// Parse command line arguments
-	if len(os.Args) < 2 {
-		showHelp()
-		return
+	env := &cliEnv{cfg: cfg, dataDir: dataDir}
+	defer env.close()
+	return newApp(env).Execute(os.Args[1:])
+}
+
+// cliEnv is what commands run against: the config and data directory as
+// adjusted by global flags, and the storage opened before the command runs
+type cliEnv struct {
+	cfg     *config.Config
+	dataDir string
+	store   storage.Storage
+}
+
+// open opens the storage selected in the config and closes out any
+// pomodoro left behind by a process that died. Task history attributes the
+// changes made through it to source, such as "cli edit".
+func (e *cliEnv) open(source string) error {
+	store, err := openStorage(e.cfg, e.dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
+	store.SetEventSource(source)
+	e.store = store
+	closeStalePomodoro(store, e.dataDir)
+	return nil
+}
 
-	cmd := strings.ToLower(os.Args[1])
-	args := os.Args[2:]
-
-	switch cmd {
-	case "add", "task", "new":
-		handleAddTask(store, args)
-	case "list", "ls":
-		handleListTasks(store, args)
-	case "complete", "done":
-		handleCompleteTask(store, args)
-	case "delete", "rm":
-		handleDeleteTask(store, args)
-	case "status", "stat":
-		handleStatus(store, args)
-	case "schedule", "sched":
-		handleSchedule(store, args)
-	case "stats":
-		handleStats(store, args)
-	case "tui", "interactive":
-		fmt.Println("TUI mode not implemented yet. Use CLI commands for now.")
-	case "version", "--version", "-v":
-		fmt.Printf("%s %s\n", appName, version)
-	case "help", "--help", "-h":
-		showHelp()
-	case "backup":
-		handleBackup(store)
-	case "data-dir":
-		fmt.Printf("Data directory: %s\n", dataDir)
-	default:
-		fmt.Printf("Unknown command: %s\n", cmd)
-		showHelp()
-		os.Exit(1)
+func (e *cliEnv) close() {
+	if e.store != nil {
+		e.store.Close()
 	}
 }
 
-func handleAddTask(store storage.Storage, args []string) {
-	if len(args) == 0 {
-		fmt.Println("Usage: qomoboro add \"<title>\" [description] [work] [play] [learn]")
-		fmt.Println("Example: qomoboro add \"Fix bug in API\" \"Memory leak in handler\" 4 1 3")
-		return
+// newApp defines the global flags and commands of the command line
+func newApp(env *cliEnv) *cli.App {
+	app := &cli.App{
+		Name:    appName,
+		Version: version,
+		Header:  fmt.Sprintf("%s\n%s %s - Canonical Hours Task Manager", ascii, appName, version),
+		Footer:  fmt.Sprintf(helpFooter, appName),
+	}
+
+	flags := app.Flags()
+	flags.Func("storage", "Storage `backend`: file or sqlite (default from config)", func(value string) error {
+		switch value {
+		case config.BackendFile, config.BackendSQLite:
+			env.cfg.Storage.Backend = value
+			return nil
+		}
+		return fmt.Errorf("want %s or %s", config.BackendFile, config.BackendSQLite)
+	})
+	flags.StringVar(&env.dataDir, "data-dir", env.dataDir, "Keep data in `DIR`")
+	flags.BoolVar(&noColor, "no-color", noColor, "Disable colored output (also set by NO_COLOR)")
+
+	app.Before = func(path string) error {
+		if noColor {
+			lipgloss.SetColorProfile(termenv.Ascii)
+		}
+		return env.open(strings.TrimSpace("cli " + path))
+	}
+	app.Default = func() error {
+		if env.cfg.UI.TUIByDefault && isTerminal(os.Stdout) {
+			return runTUI(env)
+		}
+		app.PrintHelp()
+		return nil
 	}
 
-	title := args[0]
-	description := ""
-	work, play, learn := 0, 0, 0
+	// taskCommand runs a handler that takes the storage and arguments
+	taskCommand := func(handle func(storage.Storage, []string) error) func([]string) error {
+		return func(args []string) error { return handle(env.store, args) }
+	}
+	// reportCommand gives cmd --output and runs a handler that takes the
+	// storage and the chosen format
+	reportCommand := func(cmd *cli.Command, handle func(storage.Storage, output.Format) error) *cli.Command {
+		var format output.Format
+		outputFlag(cmd.Flags(), &format)
+		cmd.Run = cli.NoArgs(func() error { return handle(env.store, format) })
+		return cmd
+	}
+	// bulkCommand gives cmd --where and --yes and runs a handler that takes
+	// the storage, those options and the arguments
+	bulkCommand := func(cmd *cli.Command, handle func(storage.Storage, bulkOptions, []string) error) *cli.Command {
+		var opts bulkOptions
+		fs := cmd.Flags()
+		fs.StringVar(&opts.where, "where", "", "Act on every task matching the `query` instead of one task")
+		fs.BoolVar(&opts.yes, "yes", false, "Do not ask for confirmation")
+		fs.BoolVar(&opts.yes, "y", false, "Do not ask for confirmation")
+		cmd.Run = func(args []string) error { return handle(env.store, opts, args) }
+		return cmd
+	}
 
-	// Parse optional arguments
+	app.Commands = []*cli.Command{
+		addCommand(env),
+		listCommand(env),
+		bulkCommand(&cli.Command{
+			Name:    "complete",
+			Aliases: []string{"done"},
+			Args:    "[id|title]",
+			Summary: "Mark a task as completed",
+			Help:    "Without a task, asks which open task to complete. With --where, completes every open task matching the query after confirmation.",
+			Examples: []string{
+				"complete 12",
+				"complete bug    # The open task whose title contains \"bug\"",
+				"complete --where 'tag:release due:today'",
+			},
+		}, handleCompleteTask),
+		bulkCommand(&cli.Command{
+			Name:     "delete",
+			Aliases:  []string{"rm"},
+			Args:     "[id|title]",
+			Summary:  "Delete a task",
+			Help:     "Asks for confirmation first. With --where, deletes every task matching the query.",
+			Examples: []string{"delete --where 'status:cancelled created:last-month'"},
+		}, handleDeleteTask),
+		editCommand(env),
+		showCommand(env),
+		historyCommand(env),
+		{
+			Name:    "start",
+			Args:    "[id|title]",
+			Summary: "Start working on a task (pauses the running task)",
+			Help:    "Starting a paused task resumes it.",
+			Run:     taskCommand(handleStartTask),
+		},
+		{
+			Name:    "pause",
+			Args:    "[id|title]",
+			Summary: "Pause the running task",
+			Run:     taskCommand(handlePauseTask),
+		},
+		{
+			Name:    "resume",
+			Args:    "[id|title]",
+			Summary: "Resume a paused task",
+			Run:     taskCommand(handleResumeTask),
+		},
+		{
+			Name:    "stop",
+			Args:    "[id|title]",
+			Summary: "Stop working on a task, keeping the time logged so far",
+			Run:     taskCommand(handleStopTask),
+		},
+		{
+			Name:    "log",
+			Args:    "<id|title> [YYYY-MM-DD] <HH:MM-HH:MM> [note]",
+			Summary: "Record time worked on a task that was not tracked live",
+			Examples: []string{
+				`log bug 14:00-15:30 "Pairing on the fix"`,
+				"log 12 2024-03-04 09:00-10:15",
+			},
+			Run: taskCommand(handleLogTime),
+		},
+		pomoCommand(env),
+		reportCommand(&cli.Command{
+			Name:    "status",
+			Aliases: []string{"stat"},
+			Summary: "Show current canonical hour and task summary",
+		}, handleStatus),
+		reportCommand(&cli.Command{
+			Name:    "schedule",
+			Aliases: []string{"sched"},
+			Summary: "Display the canonical hours schedule",
+		}, handleSchedule),
+		reportCommand(&cli.Command{
+			Name:     "stats",
+			Summary:  "Show today's productivity statistics",
+			Examples: []string{"stats -o tsv"},
+		}, handleStats),
+		backupCommand(env),
+		{
+			Name:    "tui",
+			Aliases: []string{"interactive"},
+			Summary: "Open the interactive interface",
+			Run:     cli.NoArgs(func() error { return runTUI(env) }),
+		},
+		{
+			Name:    "data-dir",
+			Summary: "Show data directory location",
+			Run: cli.NoArgs(func() error {
+				fmt.Printf("Data directory: %s\n", env.dataDir)
+				return nil
+			}),
+		},
+		{
+			Name:    "migrate",
+			Args:    "[json-data-dir]",
+			Summary: "Import a JSON data directory into the SQLite database",
+			Help:    "Imports the data directory itself unless another is given.",
+			Run: func(args []string) error {
+				if len(args) > 1 {
+					return cli.Usagef("unexpected argument %q", args[1])
+				}
+				return handleMigrate(env.dataDir, args)
+			},
+		},
+		{
+			Name:    "version",
+			Summary: "Show version information",
+			Run: cli.NoArgs(func() error {
+				fmt.Printf("%s %s\n", appName, version)
+				return nil
+			}),
+		},
+	}
+	return app
+}
+
+// addOptions are the task details given to add as flags
+type addOptions struct {
+	description string
+	score       models.Score
+	scoreFlags  bool // Any score was given as a flag
+	tags        []string
+	hour        string
+	due         *time.Time
+	estimate    time.Duration
+}
+
+// addCommand creates tasks. Scores may be given as flags or, as before
+// flags existed, as positional arguments after the description.
+func addCommand(env *cliEnv) *cli.Command {
+	var opts addOptions
+	cmd := &cli.Command{
+		Name:    "add",
+		Aliases: []string{"task", "new"},
+		Args:    "<title> [description] [work] [play] [learn]",
+		Summary: "Create a new task with optional scores (0-5)",
+		Examples: []string{
+			`add "Fix API bug" "Memory leak" 4 1 3`,
+			`add "Fix API bug" --work 4 --tags api,bug --hour Prime --due tomorrow --estimate 45m`,
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&opts.description, "description", "", "Task `text`, instead of the second argument")
+	for _, score := range []struct {
+		name  string
+		value *int
+	}{
+		{"work", &opts.score.Work},
+		{"play", &opts.score.Play},
+		{"learn", &opts.score.Learn},
+	} {
+		fs.Func(score.name, strings.ToUpper(score.name[:1])+score.name[1:]+" `score` (0-5)", func(value string) error {
+			n, err := parseScore(value)
+			if err != nil {
+				return err
+			}
+			*score.value = n
+			opts.scoreFlags = true
+			return nil
+		})
+	}
+	fs.Func("tags", "Comma-separated `list` of tags; may be repeated", func(value string) error {
+		opts.tags = append(opts.tags, splitTags(value)...)
+		return nil
+	})
+	fs.StringVar(&opts.hour, "hour", "", "Canonical hour to work in, by `name`, e.g. Prime")
+	fs.Func("due", "Due `date`: today, tomorrow, a weekday, +3d or YYYY-MM-DD", func(value string) error {
+		due, err := models.ParseDate(value, time.Now())
+		if err != nil {
+			return err
+		}
+		opts.due = &due
+		return nil
+	})
+	fs.DurationVar(&opts.estimate, "estimate", 0, "Estimated `duration`, e.g. 45m or 2h")
+
+	cmd.Run = func(args []string) error {
+		return handleAddTask(env.store, opts, args)
+	}
+	return cmd
+}
+
+func handleAddTask(store storage.Storage, opts addOptions, args []string) error {
+	if len(args) == 0 {
+		return cli.Usagef("missing task title")
+	}
+	if len(args) > 5 {
+		return cli.Usagef("too many arguments; quote a title that has spaces")
+	}
+
+	title := strings.TrimSpace(args[0])
+	if title == "" {
+		return cli.Usagef("task title must not be empty")
+	}
+	description := opts.description
 	if len(args) > 1 {
+		if description != "" {
+			return cli.Usagef("give the description either as an argument or with --description")
+		}
 		description = args[1]
 	}
+
+	// Parse optional scores
+	score := opts.score
 	if len(args) > 2 {
-		if w, err := strconv.Atoi(args[2]); err == nil && w >= 0 && w <= 5 {
-			work = w
+		if opts.scoreFlags {
+			return cli.Usagef("give scores either as arguments or with --work, --play and --learn")
 		}
-	}
-	if len(args) > 3 {
-		if p, err := strconv.Atoi(args[3]); err == nil && p >= 0 && p <= 5 {
-			play = p
+		values := []*int{&score.Work, &score.Play, &score.Learn}
+		for i, arg := range args[2:] {
+			n, err := parseScore(arg)
+			if err != nil {
+				return &cli.UsageError{Err: err}
+			}
+			*values[i] = n
 		}
 	}
-	if len(args) > 4 {
-		if l, err := strconv.Atoi(args[4]); err == nil && l >= 0 && l <= 5 {
-			learn = l
-		}
+	if opts.estimate < 0 {
+		return cli.Usagef("--estimate must not be negative")
 	}
 
+	now := time.Now()
 	task := &models.Task{
-		ID:          fmt.Sprintf("task_%d", time.Now().UnixNano()),
-		Title:       title,
-		Description: description,
-		Score: models.Score{
-			Work:  work,
-			Play:  play,
-			Learn: learn,
-		},
-		Status:    models.TaskStatusPending,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:                fmt.Sprintf("task_%d", now.UnixNano()),
+		Title:             title,
+		Description:       description,
+		Score:             score,
+		Status:            models.TaskStatusPending,
+		EstimatedDuration: opts.estimate,
+		DueDate:           opts.due,
+		Tags:              opts.tags,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	if opts.hour != "" {
+		hour, err := canonicalHourName(store, opts.hour)
+		if err != nil {
+			return err
+		}
+		task.CanonicalHour = hour
 	}
 
 	if err := store.CreateTask(task); err != nil {
-		fmt.Printf("Error creating task: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to create task: %w", err)
 	}
+	refreshStats(store)
 
-	fmt.Printf("✅ Task created: %s\n", title)
-	fmt.Printf("   Scores: Work %d, Play %d, Learn %d\n", work, play, learn)
+	fmt.Printf("✅ Task created: %s %s\n", task.Ref(), title)
+	fmt.Printf("   Scores: Work %d, Play %d, Learn %d\n", score.Work, score.Play, score.Learn)
+	if details := taskDetails(task, now); details != "" {
+		fmt.Printf("   %s\n", colorize(details, "dim"))
+	}
+	return nil
 }
 
-func handleListTasks(store storage.Storage, args []string) {
-	tasks, err := store.ListTasks()
+// parseScore parses a Work, Play or Learn score
+func parseScore(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > 5 {
+		return 0, fmt.Errorf("invalid score %q, want a whole number from 0 to 5", value)
+	}
+	return n, nil
+}
+
+// splitTags splits a comma-separated list of tags, dropping empty ones
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// canonicalHourName returns the name of the canonical hour called name in
+// any case, or a usage error listing the hours when there is none
+func canonicalHourName(store storage.Storage, name string) (string, error) {
+	schedule, err := store.GetSchedule()
 	if err != nil {
-		fmt.Printf("Error loading tasks: %v\n", err)
-		os.Exit(1)
+		return "", fmt.Errorf("failed to load schedule: %w", err)
 	}
+	hour := findCanonicalHour(schedule, name)
+	if hour == nil {
+		var names []string
+		for _, h := range schedule.Hours {
+			names = append(names, h.Name)
+		}
+		return "", cli.Usagef("unknown canonical hour %q (want one of %s)", name, strings.Join(names, ", "))
+	}
+	return hour.Name, nil
+}
 
-	if len(tasks) == 0 {
+// findCanonicalHour returns the hour of schedule with the given name,
+// ignoring case
+func findCanonicalHour(schedule *models.Schedule, name string) *models.CanonicalHour {
+	for i := range schedule.Hours {
+		if strings.EqualFold(schedule.Hours[i].Name, name) {
+			return &schedule.Hours[i]
+		}
+	}
+	return nil
+}
+
+// taskDetails summarizes the tags, canonical hour, due date and estimate
+// of a task on one line, empty when it has none of them
+func taskDetails(task *models.Task, now time.Time) string {
+	var details []string
+	if len(task.Tags) > 0 {
+		details = append(details, "#"+strings.Join(task.Tags, " #"))
+	}
+	if task.CanonicalHour != "" {
+		details = append(details, task.CanonicalHour)
+	}
+	if task.DueDate != nil {
+		due := "due " + task.DueDate.Format("Mon Jan 2")
+		if task.IsOverdue(now) {
+			due += " (overdue)"
+		}
+		details = append(details, due)
+	}
+	if task.EstimatedDuration > 0 {
+		details = append(details, "estimate "+formatDuration(task.EstimatedDuration))
+	}
+	return strings.Join(details, " · ")
+}
+
+// listOptions are the flags of list
+type listOptions struct {
+	format output.Format
+	sort   query.Sort
+	limit  int
+}
+
+// listCommand shows the tasks matching a query
+func listCommand(env *cliEnv) *cli.Command {
+	var opts listOptions
+	cmd := &cli.Command{
+		Name:    "list",
+		Aliases: []string{"ls"},
+		Args:    "[query...]",
+		Summary: "Show tasks with their IDs, status and scores, optionally filtered",
+		Help: `Shows the tasks matching every term of the query, or all tasks.
+Terms are field:value pairs or text to find in titles and descriptions:
+
+    status:open      pending, active, paused, completed, cancelled or open
+    tag:api          hour:Prime          title:bug          id>10
+    work>=4          score<6 (the total of work, play and learn)
+    estimate>30m     due:none            due:overdue
+    created:this-week     also updated, completed and due; with today,
+                          yesterday, last-week, this-month, last-month,
+                          this-year or a day such as fri or 2024-03-15
+
+A leading - negates a term, and each argument is one term, so quotes
+group words into one phrase.
+--sort takes id, title, status, work, play, learn, score, estimate,
+created, updated, completed and due.`,
+		Examples: []string{
+			`list status:pending tag:api work>=4 "memory leak"`,
+			"list status:open --sort -score,due --limit 5",
+			"list created:this-week -o json    # Export this week's tasks",
+		},
+		Run: func(args []string) error { return handleListTasks(env.store, opts, args) },
+	}
+
+	fs := cmd.Flags()
+	outputFlag(fs, &opts.format)
+	fs.Func("sort", "Comma-separated `fields` to sort by, each prefixed with - for descending", func(value string) error {
+		sort, err := query.ParseSort(value)
+		opts.sort = sort
+		return err
+	})
+	fs.IntVar(&opts.limit, "limit", 0, "Show at most `N` tasks")
+	return cmd
+}
+
+func handleListTasks(store storage.Storage, opts listOptions, args []string) error {
+	if opts.limit < 0 {
+		return cli.Usagef("--limit must not be negative")
+	}
+	q, err := query.ParseArgs(args, time.Now())
+	if err != nil {
+		return &cli.UsageError{Err: err}
+	}
+
+	allTasks, err := store.ListTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+	tasks := q.Filter(allTasks)
+	opts.sort.Apply(tasks)
+	if opts.limit > 0 && len(tasks) > opts.limit {
+		tasks = tasks[:opts.limit]
+	}
+	if !opts.format.IsText() {
+		return writeTasks(opts.format, tasks)
+	}
+
+	if len(allTasks) == 0 {
 		fmt.Println("No tasks yet. Create one with: qomoboro add \"Task title\"")
-		return
+		return nil
+	}
+	if len(tasks) == 0 {
+		fmt.Println("🔍 No tasks match the query")
+		return nil
 	}
 
 	fmt.Printf("%s\n", ascii)
-	fmt.Printf("📋 Your Tasks (%d total)\n", len(tasks))
+	if len(tasks) < len(allTasks) {
+		fmt.Printf("📋 Your Tasks (%d of %d)\n", len(tasks), len(allTasks))
+	} else {
+		fmt.Printf("📋 Your Tasks (%d total)\n", len(tasks))
+	}
 	fmt.Println(strings.Repeat("─", 60))
 
 	pending := 0
+	inProgress := 0
 	completed := 0
+	now := time.Now()
 
-	for i, task := range tasks {
+	for _, task := range tasks {
 		status := getStatusEmoji(task.Status)
 		scores := fmt.Sprintf("W:%d P:%d L:%d", task.Score.Work, task.Score.Play, task.Score.Learn)
+		if details := taskDetails(task, now); details != "" {
+			scores += " · " + details
+		}
 
-		fmt.Printf("%2d. %s %s %s\n", i+1, status, task.Title, colorize(scores, "dim"))
+		fmt.Printf("%4s %s %s %s\n", task.Ref(), status, task.Title, colorize(scores, "dim"))
 
 		if task.Description != "" {
 			fmt.Printf("     %s\n", colorize(task.Description, "dim"))
@@ -168,90 +590,59 @@ func handleListTasks(store storage.Storage, args []string) {
 
 		if task.Status == models.TaskStatusPending {
 			pending++
+		} else if task.Status == models.TaskStatusActive || task.Status == models.TaskStatusPaused {
+			inProgress++
 		} else if task.Status == models.TaskStatusCompleted {
 			completed++
 		}
 	}
 
 	fmt.Println(strings.Repeat("─", 60))
-	fmt.Printf("📊 Status: %d pending, %d completed\n", pending, completed)
+	fmt.Printf("📊 Status: %d pending, %d in progress, %d completed\n", pending, inProgress, completed)
+	return nil
 }
 
-func handleCompleteTask(store storage.Storage, args []string) {
+func handleCompleteTask(store storage.Storage, opts bulkOptions, args []string) error {
 	tasks, err := store.ListTasks()
 	if err != nil {
-		fmt.Printf("Error loading tasks: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to load tasks: %w", err)
 	}
 
-	// Filter to pending tasks only
-	var pendingTasks []*models.Task
+	// Filter to tasks that are not finished yet
+	var openTasks []*models.Task
 	for _, task := range tasks {
-		if task.Status == models.TaskStatusPending {
-			pendingTasks = append(pendingTasks, task)
+		switch task.Status {
+		case models.TaskStatusPending, models.TaskStatusActive, models.TaskStatusPaused:
+			openTasks = append(openTasks, task)
 		}
 	}
 
-	if len(pendingTasks) == 0 {
+	if len(openTasks) == 0 {
 		fmt.Println("🎉 No pending tasks! All done.")
-		return
+		return nil
 	}
 
-	var task *models.Task
-
-	if len(args) == 0 {
-		// Interactive selection
-		fmt.Println("📋 Pending tasks:")
-		for i, t := range pendingTasks {
-			scores := fmt.Sprintf("W:%d P:%d L:%d", t.Score.Work, t.Score.Play, t.Score.Learn)
-			fmt.Printf("%2d. %s %s\n", i+1, t.Title, colorize(scores, "dim"))
-		}
-		fmt.Print("\nWhich task to complete? (1-" + fmt.Sprintf("%d", len(pendingTasks)) + "): ")
-
-		var choice int
-		if _, err := fmt.Scanf("%d", &choice); err != nil || choice < 1 || choice > len(pendingTasks) {
-			fmt.Println("❌ Invalid selection")
-			return
+	if opts.where != "" {
+		selected, err := selectMatchingTasks(openTasks, args, opts, "open", "complete")
+		if err != nil || len(selected) == 0 {
+			return err
 		}
-		task = pendingTasks[choice-1]
-	} else {
-		// Try to parse as number first
-		if taskNum, err := strconv.Atoi(args[0]); err == nil {
-			if taskNum < 1 || taskNum > len(pendingTasks) {
-				fmt.Printf("❌ Task number %d out of range (1-%d pending tasks)\n", taskNum, len(pendingTasks))
-				return
-			}
-			task = pendingTasks[taskNum-1]
-		} else {
-			// Try partial title matching
-			query := strings.ToLower(args[0])
-			var matches []*models.Task
-			for _, t := range pendingTasks {
-				if strings.Contains(strings.ToLower(t.Title), query) {
-					matches = append(matches, t)
-				}
-			}
-
-			if len(matches) == 0 {
-				fmt.Printf("❌ No pending tasks match '%s'\n", args[0])
-				return
-			} else if len(matches) == 1 {
-				task = matches[0]
-			} else {
-				fmt.Printf("🤔 Multiple tasks match '%s':\n", args[0])
-				for i, t := range matches {
-					fmt.Printf("%2d. %s\n", i+1, t.Title)
-				}
-				fmt.Print("Which one? (1-" + fmt.Sprintf("%d", len(matches)) + "): ")
-
-				var choice int
-				if _, err := fmt.Scanf("%d", &choice); err != nil || choice < 1 || choice > len(matches) {
-					fmt.Println("❌ Invalid selection")
-					return
-				}
-				task = matches[choice-1]
+		var days []time.Time
+		defer func() { refreshStats(store, days...) }()
+		for _, task := range selected {
+			days = append(days, stats.TaskDays(task, time.Now())...)
+			task.Complete()
+			if err := store.UpdateTask(task); err != nil {
+				return fmt.Errorf("failed to update task: %w", err)
 			}
+			fmt.Printf("🎉 Done! %s\n", task.Title)
 		}
+		return nil
+	}
+
+	task, err := selectTask(openTasks, args, "open", "complete")
+	if err != nil {
+		return err
 	}
 
 	// Confirm completion
@@ -264,82 +655,45 @@ func handleCompleteTask(store storage.Storage, args []string) {
 	task.Complete()
 
 	if err := store.UpdateTask(task); err != nil {
-		fmt.Printf("Error updating task: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to update task: %w", err)
 	}
+	refreshStats(store, stats.TaskDays(task, time.Now())...)
 
 	fmt.Printf("🎉 Done! %s\n", task.Title)
+	return nil
 }
 
-func handleDeleteTask(store storage.Storage, args []string) {
+func handleDeleteTask(store storage.Storage, opts bulkOptions, args []string) error {
 	tasks, err := store.ListTasks()
 	if err != nil {
-		fmt.Printf("Error loading tasks: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to load tasks: %w", err)
 	}
 
 	if len(tasks) == 0 {
 		fmt.Println("📝 No tasks to delete")
-		return
+		return nil
 	}
 
-	var task *models.Task
-
-	if len(args) == 0 {
-		// Interactive selection
-		fmt.Println("📋 All tasks:")
-		for i, t := range tasks {
-			status := getStatusEmoji(t.Status)
-			scores := fmt.Sprintf("W:%d P:%d L:%d", t.Score.Work, t.Score.Play, t.Score.Learn)
-			fmt.Printf("%2d. %s %s %s\n", i+1, status, t.Title, colorize(scores, "dim"))
-		}
-		fmt.Print("\nWhich task to delete? (1-" + fmt.Sprintf("%d", len(tasks)) + "): ")
-
-		var choice int
-		if _, err := fmt.Scanf("%d", &choice); err != nil || choice < 1 || choice > len(tasks) {
-			fmt.Println("❌ Invalid selection")
-			return
+	if opts.where != "" {
+		selected, err := selectMatchingTasks(tasks, args, opts, "", "delete")
+		if err != nil || len(selected) == 0 {
+			return err
 		}
-		task = tasks[choice-1]
-	} else {
-		// Try to parse as number first
-		if taskNum, err := strconv.Atoi(args[0]); err == nil {
-			if taskNum < 1 || taskNum > len(tasks) {
-				fmt.Printf("❌ Task number %d out of range (1-%d)\n", taskNum, len(tasks))
-				return
-			}
-			task = tasks[taskNum-1]
-		} else {
-			// Try partial title matching
-			query := strings.ToLower(args[0])
-			var matches []*models.Task
-			for _, t := range tasks {
-				if strings.Contains(strings.ToLower(t.Title), query) {
-					matches = append(matches, t)
-				}
-			}
-
-			if len(matches) == 0 {
-				fmt.Printf("❌ No tasks match '%s'\n", args[0])
-				return
-			} else if len(matches) == 1 {
-				task = matches[0]
-			} else {
-				fmt.Printf("🤔 Multiple tasks match '%s':\n", args[0])
-				for i, t := range matches {
-					status := getStatusEmoji(t.Status)
-					fmt.Printf("%2d. %s %s\n", i+1, status, t.Title)
-				}
-				fmt.Print("Which one to delete? (1-" + fmt.Sprintf("%d", len(matches)) + "): ")
-
-				var choice int
-				if _, err := fmt.Scanf("%d", &choice); err != nil || choice < 1 || choice > len(matches) {
-					fmt.Println("❌ Invalid selection")
-					return
-				}
-				task = matches[choice-1]
+		var days []time.Time
+		defer func() { refreshStats(store, days...) }()
+		for _, task := range selected {
+			days = append(days, stats.TaskDays(task, time.Now())...)
+			if err := store.DeleteTask(task.ID); err != nil {
+				return fmt.Errorf("failed to delete task: %w", err)
 			}
+			fmt.Printf("🗑️  Deleted: %s\n", task.Title)
 		}
+		return nil
+	}
+
+	task, err := selectTask(tasks, args, "", "delete")
+	if err != nil {
+		return err
 	}
 
 	// Confirm deletion
@@ -350,71 +704,467 @@ func handleDeleteTask(store storage.Storage, args []string) {
 		fmt.Printf("   %s\n", colorize(task.Description, "dim"))
 	}
 
-	fmt.Print("Are you sure? (y/N): ")
-	var confirm string
-	fmt.Scanf("%s", &confirm)
-	if strings.ToLower(confirm) != "y" && strings.ToLower(confirm) != "yes" {
+	if !opts.yes && !confirm("Are you sure?") {
 		fmt.Println("❌ Cancelled")
-		return
+		return nil
 	}
 
 	if err := store.DeleteTask(task.ID); err != nil {
-		fmt.Printf("Error deleting task: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to delete task: %w", err)
 	}
+	refreshStats(store, stats.TaskDays(task, time.Now())...)
 
 	fmt.Printf("🗑️  Deleted: %s\n", task.Title)
+	return nil
+}
+
+func handleStartTask(store storage.Storage, args []string) error {
+	tasks, err := store.ListTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	// Paused tasks can be started again, which resumes them
+	var startable []*models.Task
+	for _, task := range tasks {
+		if task.Status == models.TaskStatusPending || task.Status == models.TaskStatusPaused {
+			startable = append(startable, task)
+		}
+	}
+
+	if len(startable) == 0 {
+		if active := tasksWithStatus(tasks, models.TaskStatusActive); len(active) > 0 {
+			fmt.Printf("▶️  Already working on: %s\n", active[0].Title)
+			return nil
+		}
+		fmt.Println("📝 No tasks to start. Create one with: qomoboro add \"Task title\"")
+		return nil
+	}
+
+	task, err := selectTask(startable, args, "pending", "start")
+	if err != nil {
+		return err
+	}
+
+	if err := pauseActiveTasks(store, tasks, task); err != nil {
+		return fmt.Errorf("failed to pause running task: %w", err)
+	}
+
+	if task.Status == models.TaskStatusPaused {
+		task.Resume()
+	} else {
+		task.Start()
+	}
+	labelTimeEntries(store, task)
+
+	if err := store.UpdateTask(task); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	refreshStats(store)
+
+	fmt.Printf("▶️  Started: %s\n", task.Title)
+	if task.ActualDuration > 0 {
+		fmt.Printf("   %s\n", colorize(formatDuration(task.ActualDuration)+" logged so far", "dim"))
+	}
+	return nil
+}
+
+func handlePauseTask(store storage.Storage, args []string) error {
+	tasks, err := store.ListTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	active := tasksWithStatus(tasks, models.TaskStatusActive)
+	if len(active) == 0 {
+		fmt.Println("⏹️  No task is running. Start one with: qomoboro start <task>")
+		return nil
+	}
+
+	task, err := selectOnlyOrTask(active, args, "active", "pause")
+	if err != nil {
+		return err
+	}
+
+	task.Pause()
+
+	if err := store.UpdateTask(task); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	refreshStats(store)
+
+	fmt.Printf("⏸️  Paused: %s %s\n", task.Title, colorize(formatDuration(task.ActualDuration)+" so far", "dim"))
+	return nil
+}
+
+func handleResumeTask(store storage.Storage, args []string) error {
+	tasks, err := store.ListTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	paused := tasksWithStatus(tasks, models.TaskStatusPaused)
+	if len(paused) == 0 {
+		fmt.Println("⏸️  No paused tasks")
+		return nil
+	}
+
+	task, err := selectOnlyOrTask(paused, args, "paused", "resume")
+	if err != nil {
+		return err
+	}
+
+	if err := pauseActiveTasks(store, tasks, task); err != nil {
+		return fmt.Errorf("failed to pause running task: %w", err)
+	}
+
+	task.Resume()
+	labelTimeEntries(store, task)
+
+	if err := store.UpdateTask(task); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	refreshStats(store)
+
+	fmt.Printf("▶️  Resumed: %s %s\n", task.Title, colorize(formatDuration(task.ActualDuration)+" so far", "dim"))
+	return nil
+}
+
+func handleStopTask(store storage.Storage, args []string) error {
+	tasks, err := store.ListTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	// Prefer the running task when no task is named
+	candidates := tasksWithStatus(tasks, models.TaskStatusActive)
+	if len(args) > 0 || len(candidates) == 0 {
+		candidates = append(candidates, tasksWithStatus(tasks, models.TaskStatusPaused)...)
+	}
+	if len(candidates) == 0 {
+		fmt.Println("⏹️  No task is running. Start one with: qomoboro start <task>")
+		return nil
+	}
+
+	task, err := selectOnlyOrTask(candidates, args, "started", "stop")
+	if err != nil {
+		return err
+	}
+
+	task.Stop()
+
+	if err := store.UpdateTask(task); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	refreshStats(store)
+
+	fmt.Printf("⏹️  Stopped: %s %s\n", task.Title, colorize(formatDuration(task.ActualDuration)+" logged", "dim"))
+	return nil
+}
+
+func handleLogTime(store storage.Storage, args []string) error {
+	if len(args) < 2 {
+		return cli.Usagef("missing task or time range")
+	}
+
+	// An optional date comes before the time range; default to today
+	date := time.Now()
+	rest := args[1:]
+	if d, err := time.ParseInLocation("2006-01-02", rest[0], time.Local); err == nil {
+		date = d
+		rest = rest[1:]
+	}
+	if len(rest) == 0 {
+		return cli.Usagef("missing time range, e.g. 14:00-15:30")
+	}
+
+	start, end, err := parseTimeRange(date, rest[0])
+	if err != nil {
+		return &cli.UsageError{Err: err}
+	}
+	if end.After(time.Now()) {
+		return fmt.Errorf("cannot log time in the future (%s ends after now)", rest[0])
+	}
+	note := strings.Join(rest[1:], " ")
+
+	tasks, err := store.ListTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+	if len(tasks) == 0 {
+		fmt.Println("📝 No tasks yet. Create one with: qomoboro add \"Task title\"")
+		return nil
+	}
+
+	task, err := selectTask(tasks, args[:1], "", "log time on")
+	if err != nil {
+		return err
+	}
+
+	if err := task.LogTime(start, end, note); err != nil {
+		return err
+	}
+	labelTimeEntries(store, task)
+
+	if err := store.UpdateTask(task); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	refreshStats(store, start, end)
+
+	fmt.Printf("⏱️  Logged %s on %s %s\n", formatDuration(end.Sub(start)), task.Title,
+		colorize(fmt.Sprintf("(%s, %s total)", start.Format("Jan 2 15:04")+"-"+end.Format("15:04"), formatDuration(task.Elapsed(time.Now()))), "dim"))
+	return nil
 }
 
-func handleStatus(store storage.Storage, args []string) {
+// parseTimeRange parses an "HH:MM-HH:MM" range on the given date
+func parseTimeRange(date time.Time, value string) (time.Time, time.Time, error) {
+	from, to, ok := strings.Cut(value, "-")
+	if !ok {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid time range %q, want HH:MM-HH:MM", value)
+	}
+
+	parse := func(clock string) (time.Time, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(clock))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q, want HH:MM", clock)
+		}
+		year, month, day := date.Date()
+		return time.Date(year, month, day, t.Hour(), t.Minute(), 0, 0, time.Local), nil
+	}
+
+	start, err := parse(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := parse(to)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("time range %q must end after it starts", value)
+	}
+
+	return start, end, nil
+}
+
+// labelTimeEntries tags new time entries with the canonical hour they
+// started in
+func labelTimeEntries(store storage.Storage, task *models.Task) {
 	schedule, err := store.GetSchedule()
 	if err != nil {
-		fmt.Printf("Error loading schedule: %v\n", err)
-		os.Exit(1)
+		fmt.Printf("⚠️  Could not label time by canonical hour: %v\n", err)
+		return
 	}
+	task.AssignCanonicalHours(schedule)
+}
 
-	now := time.Now()
-	currentHour := schedule.GetCurrentHour(now)
+// selectTask picks one of candidates by short ID, full ID or unambiguous
+// prefix of it, or partial title, asking interactively when args is empty or
+// the title is ambiguous. kind describes the candidates in messages, e.g.
+// "pending". It returns an error when nothing was selected.
+func selectTask(candidates []*models.Task, args []string, kind, action string) (*models.Task, error) {
+	noun := "tasks"
+	if kind != "" {
+		noun = kind + " tasks"
+	}
+
+	if len(args) == 0 {
+		// Interactive selection
+		fmt.Printf("📋 %s:\n", strings.ToUpper(noun[:1])+noun[1:])
+		for _, t := range candidates {
+			status := getStatusEmoji(t.Status)
+			scores := fmt.Sprintf("W:%d P:%d L:%d", t.Score.Work, t.Score.Play, t.Score.Learn)
+			fmt.Printf("%4s %s %s %s\n", t.Ref(), status, t.Title, colorize(scores, "dim"))
+		}
+		fmt.Printf("\nWhich task to %s? (ID): ", action)
+		return readTaskChoice(candidates)
+	}
+	if len(args) > 1 {
+		return nil, cli.Usagef("unexpected argument %q; quote a title that has spaces", args[1])
+	}
+
+	ref := args[0]
+	matches := models.MatchID(candidates, ref)
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	// Fall back to partial title matching
+	if len(matches) == 0 {
+		query := strings.ToLower(ref)
+		for _, t := range candidates {
+			if strings.Contains(strings.ToLower(t.Title), query) {
+				matches = append(matches, t)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		if _, err := strconv.Atoi(strings.TrimPrefix(ref, "#")); err == nil {
+			return nil, fmt.Errorf("no %s with ID %s", noun, strings.TrimPrefix(ref, "#"))
+		}
+		return nil, fmt.Errorf("no %s match '%s'", noun, ref)
+	case 1:
+		return matches[0], nil
+	}
+
+	fmt.Printf("🤔 Multiple tasks match '%s':\n", ref)
+	for _, t := range matches {
+		status := getStatusEmoji(t.Status)
+		fmt.Printf("%4s %s %s\n", t.Ref(), status, t.Title)
+	}
+	fmt.Printf("Which one to %s? (ID): ", action)
+	return readTaskChoice(matches)
+}
+
+// bulkOptions select every task matching a query instead of one task
+type bulkOptions struct {
+	where string // Query, see list
+	yes   bool   // Skip the confirmation
+}
+
+// selectMatchingTasks returns the candidates matching the --where query
+// once the user confirms acting on them, or none when they decline
+func selectMatchingTasks(candidates []*models.Task, args []string, opts bulkOptions, kind, action string) ([]*models.Task, error) {
+	if len(args) > 0 {
+		return nil, cli.Usagef("unexpected argument %q; give either a task or --where", args[0])
+	}
+	q, err := query.Parse(opts.where, time.Now())
+	if err != nil {
+		return nil, &cli.UsageError{Err: err}
+	}
+
+	matches := q.Filter(candidates)
+	if len(matches) == 0 {
+		noun := "tasks"
+		if kind != "" {
+			noun = kind + " tasks"
+		}
+		return nil, fmt.Errorf("no %s match '%s'", noun, opts.where)
+	}
+
+	for _, t := range matches {
+		fmt.Printf("%4s %s %s\n", t.Ref(), getStatusEmoji(t.Status), t.Title)
+	}
+	question := fmt.Sprintf("%s these %d tasks?", strings.ToUpper(action[:1])+action[1:], len(matches))
+	if len(matches) == 1 {
+		question = fmt.Sprintf("%s this task?", strings.ToUpper(action[:1])+action[1:])
+	}
+	if !opts.yes && !confirm(question) {
+		fmt.Println("❌ Cancelled")
+		return nil, nil
+	}
+	return matches, nil
+}
+
+// confirm asks a yes or no question on stdin, defaulting to no
+func confirm(question string) bool {
+	fmt.Printf("%s (y/N): ", question)
+	var answer string
+	fmt.Scanf("%s", &answer)
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes"
+}
+
+// readTaskChoice reads the ID of one of tasks from stdin
+func readTaskChoice(tasks []*models.Task) (*models.Task, error) {
+	var choice string
+	fmt.Scanf("%s", &choice)
+	if matches := models.MatchID(tasks, choice); len(matches) == 1 {
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("invalid selection %q", choice)
+}
+
+// selectOnlyOrTask is selectTask, except that with no args and a single
+// candidate it picks that candidate without asking
+func selectOnlyOrTask(candidates []*models.Task, args []string, kind, action string) (*models.Task, error) {
+	if len(args) == 0 && len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	return selectTask(candidates, args, kind, action)
+}
+
+// tasksWithStatus filters tasks by status, keeping their order
+func tasksWithStatus(tasks []*models.Task, status models.TaskStatus) []*models.Task {
+	var result []*models.Task
+	for _, task := range tasks {
+		if task.Status == status {
+			result = append(result, task)
+		}
+	}
+	return result
+}
+
+// pauseActiveTasks pauses every active task other than except, so that at
+// most one task is ever running
+func pauseActiveTasks(store storage.Storage, tasks []*models.Task, except *models.Task) error {
+	for _, task := range tasks {
+		if task == except || task.Status != models.TaskStatusActive {
+			continue
+		}
+		task.Pause()
+		if err := store.UpdateTask(task); err != nil {
+			return err
+		}
+		fmt.Printf("⏸️  Paused: %s %s\n", task.Title, colorize(formatDuration(task.ActualDuration)+" so far", "dim"))
+	}
+	return nil
+}
+
+func handleStatus(store storage.Storage, format output.Format) error {
+	schedule, err := store.GetSchedule()
+	if err != nil {
+		return fmt.Errorf("failed to load schedule: %w", err)
+	}
+	tasks, err := store.ListTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	report := newStatusReport(schedule, tasks, time.Now())
+	if !format.IsText() {
+		return output.Write(os.Stdout, format, report, statusTable(report))
+	}
 
 	fmt.Printf("%s\n", ascii)
-	fmt.Printf("🕐 Current Time: %s\n", now.Format("15:04 Monday, Jan 2"))
+	fmt.Printf("🕐 Current Time: %s\n", report.Time.Format("15:04 Monday, Jan 2"))
 
-	if currentHour != nil {
+	if currentHour := report.CurrentHour; currentHour != nil {
 		fmt.Printf("📍 Current Hour: %s (%s)\n", currentHour.Name, currentHour.Description)
 		fmt.Printf("   Suggested Focus: %s\n", currentHour.Purpose)
 	} else {
 		fmt.Printf("📍 Outside canonical hours\n")
 	}
 
-	// Show task summary
-	tasks, err := store.ListTasks()
-	if err == nil {
-		pending := 0
-		completed := 0
-		totalWork, totalPlay, totalLearn := 0, 0, 0
-
-		for _, task := range tasks {
-			if task.Status == models.TaskStatusPending {
-				pending++
-			} else if task.Status == models.TaskStatusCompleted {
-				completed++
-				totalWork += task.Score.Work
-				totalPlay += task.Score.Play
-				totalLearn += task.Score.Learn
+	for _, task := range report.Active {
+		fmt.Printf("\n▶️  Working on: %s %s\n", task.Title, colorize(formatDuration(task.Elapsed(report.Time)), "dim"))
+	}
+	if len(report.Paused) > 0 {
+		fmt.Printf("⏸️  Paused: ")
+		for i, task := range report.Paused {
+			if i > 0 {
+				fmt.Print(", ")
 			}
+			fmt.Printf("%s %s", task.Title, colorize(formatDuration(task.ActualDuration), "dim"))
 		}
-
-		fmt.Printf("\n📊 Today's Progress:\n")
-		fmt.Printf("   Tasks: %d pending, %d completed\n", pending, completed)
-		fmt.Printf("   Scores: Work %d, Play %d, Learn %d\n", totalWork, totalPlay, totalLearn)
+		fmt.Println()
 	}
+
+	fmt.Printf("\n📊 Today's Progress:\n")
+	fmt.Printf("   Tasks: %d pending, %d in progress, %d completed\n", report.Pending, report.InProgress, report.Completed)
+	fmt.Printf("   Scores: Work %d, Play %d, Learn %d\n", report.Score.Work, report.Score.Play, report.Score.Learn)
+	return nil
 }
 
-func handleSchedule(store storage.Storage, args []string) {
+func handleSchedule(store storage.Storage, format output.Format) error {
 	schedule, err := store.GetSchedule()
 	if err != nil {
-		fmt.Printf("Error loading schedule: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to load schedule: %w", err)
+	}
+	if !format.IsText() {
+		return output.Write(os.Stdout, format, schedule, scheduleTable(schedule))
 	}
 
 	fmt.Printf("%s\n", ascii)
@@ -435,14 +1185,17 @@ func handleSchedule(store storage.Storage, args []string) {
 		}
 		fmt.Println()
 	}
+	return nil
 }
 
-func handleStats(store storage.Storage, args []string) {
+func handleStats(store storage.Storage, format output.Format) error {
 	today := time.Now()
 	stats, err := store.GetDailyStats(today)
 	if err != nil {
-		fmt.Printf("Error loading stats: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to load stats: %w", err)
+	}
+	if !format.IsText() {
+		return output.Write(os.Stdout, format, stats, statsTable(stats))
 	}
 
 	fmt.Printf("%s\n", ascii)
@@ -453,14 +1206,282 @@ func handleStats(store storage.Storage, args []string) {
 	fmt.Printf("Scores: Work %d, Play %d, Learn %d\n",
 		stats.TotalScore.Work, stats.TotalScore.Play, stats.TotalScore.Learn)
 	fmt.Printf("Time: %s\n", stats.TimeSpent.String())
+
+	if len(stats.TimeByHour) == 0 && stats.Interruptions == 0 {
+		return nil
+	}
+	schedule, err := store.GetSchedule()
+	if err != nil {
+		return fmt.Errorf("failed to load schedule: %w", err)
+	}
+	for _, hour := range schedule.Hours {
+		if spent := stats.TimeByHour[hour.Name]; spent > 0 {
+			fmt.Printf("   %-9s %s\n", hour.Name, colorize(formatDuration(spent), "dim"))
+		}
+	}
+
+	if stats.Interruptions == 0 {
+		return nil
+	}
+	fmt.Printf("Interruptions: %d\n", stats.Interruptions)
+	for _, hour := range schedule.Hours {
+		bucket := stats.InterruptionsByHour[hour.Name]
+		if bucket.Total() == 0 {
+			continue
+		}
+		var reasons []string
+		for _, reason := range bucket.TopReasons(3) {
+			reasons = append(reasons, fmt.Sprintf("%s ×%d", reason, bucket.Reasons[reason]))
+		}
+		fmt.Printf("   %-9s %d %s %s\n", hour.Name, bucket.Total(),
+			colorize(fmt.Sprintf("(%d internal, %d external)", bucket.Internal, bucket.External), "dim"),
+			strings.Join(reasons, ", "))
+	}
+	return nil
+}
+
+// backupCommand creates backups and manages them with its subcommands
+func backupCommand(env *cliEnv) *cli.Command {
+	var dryRun bool
+	prune := &cli.Command{
+		Name:    "prune",
+		Summary: "Delete backups outside the retention policy",
+		Run: cli.NoArgs(func() error {
+			return handleBackupPrune(env.store, retentionPolicy(env.cfg), dryRun)
+		}),
+	}
+	prune.Flags().BoolVar(&dryRun, "dry-run", false, "Only list the backups that would be deleted")
+	prune.Flags().BoolVar(&dryRun, "n", false, "Only list the backups that would be deleted")
+
+	return &cli.Command{
+		Name:    "backup",
+		Summary: "Create a compressed backup archive of all data",
+		Run:     cli.NoArgs(func() error { return handleBackupCreate(env.store) }),
+		Commands: []*cli.Command{
+			{
+				Name:    "list",
+				Aliases: []string{"ls"},
+				Summary: "Show available backups",
+				Run:     cli.NoArgs(func() error { return handleBackupList(env.store) }),
+			},
+			{
+				Name:    "restore",
+				Args:    "<id>",
+				Summary: "Restore a backup (current data is backed up first)",
+				Help:    "List backup IDs with: qomoboro backup list",
+				Run: func(args []string) error {
+					if len(args) != 1 {
+						return cli.Usagef("want exactly one backup ID")
+					}
+					return handleBackupRestore(env.store, args[0])
+				},
+			},
+			{
+				Name:    "verify",
+				Args:    "[id...]",
+				Summary: "Check backup archives against their checksums",
+				Help:    "Checks every backup unless IDs are given.",
+				Run:     func(args []string) error { return handleBackupVerify(env.store, args) },
+			},
+			prune,
+		},
+	}
+}
+
+func handleBackupCreate(store storage.Storage) error {
+	backup, err := store.CreateBackup(storage.BackupLabelManual)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	fmt.Printf("💾 Backup created: %s\n", backup.ID)
+	fmt.Printf("   %d tasks, %s\n", backup.Manifest.TaskCount, formatBytes(backup.Size))
+	fmt.Printf("   %s\n", colorize(backup.Path, "dim"))
+	return nil
+}
+
+func handleBackupList(store storage.Storage) error {
+	backups, err := store.ListBackups()
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	if len(backups) == 0 {
+		fmt.Println("No backups yet. Create one with: qomoboro backup")
+		return nil
+	}
+
+	fmt.Printf("💾 Backups (%d total)\n", len(backups))
+	fmt.Println(strings.Repeat("─", 60))
+	for _, backup := range backups {
+		if backup.Manifest == nil {
+			fmt.Printf("%-20s %s\n", backup.ID, colorize("unreadable manifest", "dim"))
+			continue
+		}
+		details := fmt.Sprintf("%d tasks, %s, %s", backup.Manifest.TaskCount, backup.Manifest.Backend, formatBytes(backup.Size))
+		if backup.Manifest.Label != "" {
+			details += ", " + backup.Manifest.Label
+		}
+		fmt.Printf("%-20s %s %s\n", backup.ID,
+			backup.Manifest.CreatedAt.Local().Format("2006-01-02 15:04"), colorize(details, "dim"))
+	}
+	return nil
+}
+
+func handleBackupRestore(store storage.Storage, id string) error {
+	backup, err := store.VerifyBackup(id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("♻️  Restoring backup %s from %s (%d tasks)\n", backup.ID,
+		backup.Manifest.CreatedAt.Local().Format("2006-01-02 15:04"), backup.Manifest.TaskCount)
+	fmt.Println("   This replaces all current tasks, schedule and stats.")
+	if !confirm("Are you sure?") {
+		fmt.Println("❌ Cancelled")
+		return nil
+	}
+
+	safety, err := store.RestoreBackup(backup.ID)
+	if safety != nil {
+		fmt.Printf("💾 Previous data saved as backup %s\n", safety.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	fmt.Printf("✅ Restored backup %s\n", backup.ID)
+	return nil
+}
+
+func handleBackupVerify(store storage.Storage, ids []string) error {
+	if len(ids) == 0 {
+		backups, err := store.ListBackups()
+		if err != nil {
+			return fmt.Errorf("failed to list backups: %w", err)
+		}
+		for _, backup := range backups {
+			ids = append(ids, backup.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		fmt.Println("No backups to verify")
+		return nil
+	}
+
+	failed := 0
+	for _, id := range ids {
+		backup, err := store.VerifyBackup(id)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ %s: %d files, %d tasks\n", backup.ID, len(backup.Manifest.Files), backup.Manifest.TaskCount)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d backups failed verification", failed, len(ids))
+	}
+	return nil
+}
+
+func handleBackupPrune(store storage.Storage, policy storage.RetentionPolicy, dryRun bool) error {
+	if !policy.Enabled() {
+		fmt.Println("Backup retention is disabled; set keep_daily, keep_weekly or keep_monthly in the config to prune")
+		return nil
+	}
+
+	var pruned []*storage.BackupInfo
+	if dryRun {
+		backups, err := store.ListBackups()
+		if err != nil {
+			return fmt.Errorf("failed to list backups: %w", err)
+		}
+		pruned = storage.BackupsToPrune(backups, policy, time.Now())
+	} else {
+		var err error
+		pruned, err = store.PruneBackups(policy)
+		if err != nil {
+			return fmt.Errorf("failed to prune backups: %w", err)
+		}
+	}
+
+	if len(pruned) == 0 {
+		fmt.Printf("✅ Nothing to prune (keeping %d daily, %d weekly, %d monthly)\n",
+			policy.KeepDaily, policy.KeepWeekly, policy.KeepMonthly)
+		return nil
+	}
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	fmt.Printf("🗑️  %s %d backups (keeping %d daily, %d weekly, %d monthly)\n",
+		verb, len(pruned), policy.KeepDaily, policy.KeepWeekly, policy.KeepMonthly)
+	for _, backup := range pruned {
+		fmt.Printf("   %s\n", colorize(backup.ID, "dim"))
+	}
+	return nil
 }
 
-func handleBackup(store storage.Storage) {
-	if err := store.Backup(); err != nil {
-		fmt.Printf("Error creating backup: %v\n", err)
-		os.Exit(1)
+// formatDuration renders a duration rounded to the minute, or to the
+// second when under a minute
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// formatBytes renders a byte count in human-readable units
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+func handleMigrate(dataDir string, args []string) error {
+	srcDir := dataDir
+	if len(args) > 0 {
+		srcDir = args[0]
+	}
+
+	db, err := storage.NewSQLiteStorage(dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	defer db.Close()
+	db.SetEventSource("cli migrate")
+
+	fmt.Printf("📦 Importing JSON data from %s\n", srcDir)
+	result, err := storage.ImportFileStorage(srcDir, db)
+	if err != nil {
+		return fmt.Errorf("failed to migrate data: %w", err)
+	}
+
+	fmt.Printf("✅ Imported %d tasks (%d already present), %d stats snapshots, %d history events\n",
+		result.Tasks, result.SkippedTasks, result.Stats, result.Events)
+	fmt.Printf("   Database: %s\n", filepath.Join(dataDir, storage.SQLiteDatabaseFile))
+	fmt.Println("   Enable it with --storage sqlite or \"storage\": {\"backend\": \"sqlite\"} in config.json")
+	return nil
+}
+
+// refreshStats recomputes today's stats snapshot after a task change, along
+// with those of the other days the change touched
+func refreshStats(store storage.Storage, days ...time.Time) {
+	if err := stats.RefreshDaily(store, append([]time.Time{time.Now()}, days...)...); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update stats: %v\n", err)
 	}
-	fmt.Println("💾 Backup created successfully")
 }
 
 func getStatusEmoji(status models.TaskStatus) string {
@@ -481,6 +1502,10 @@ func getStatusEmoji(status models.TaskStatus) string {
 }
 
 func colorize(text, style string) string {
+	if noColor {
+		return text
+	}
+
 	// Simple color codes - can be enhanced later
 	switch style {
 	case "dim":
@@ -494,6 +1519,71 @@ func colorize(text, style string) string {
 	}
 }
 
+// loadConfig reads the user configuration from the config directory
+func loadConfig() (*config.Config, error) {
+	dir, err := config.Dir(appName)
+	if err != nil {
+		return nil, err
+	}
+	return config.Load(dir)
+}
+
+// openStorage opens the storage backend selected in cfg
+func openStorage(cfg *config.Config, dataDir string) (storage.Storage, error) {
+	if cfg.Storage.Backend == config.BackendSQLite {
+		return storage.NewSQLiteStorageWithOptions(dataDir, storage.SQLiteStorageOptions{
+			Retention: retentionPolicy(cfg),
+		})
+	}
+
+	opts := storage.DefaultFileStorageOptions()
+	opts.LockTimeout = cfg.Storage.LockTimeout.Duration
+	opts.Retention = retentionPolicy(cfg)
+	opts.AutoBackup = cfg.Backup.Auto
+	return storage.NewFileStorageWithOptions(dataDir, opts)
+}
+
+// runTUI opens the interactive interface on the storage of env, with the
+// pomodoro timer set up like pomo
+func runTUI(env *cliEnv) error {
+	env.store.SetEventSource("tui")
+	app := ui.NewApp(env.store)
+
+	opts := pomodoroDefaults(env.cfg)
+	notifier, err := pomodoroNotifier(opts)
+	if err != nil {
+		return err
+	}
+	app.SetPomodoro(ui.PomodoroOptions{
+		Config:    opts.Config,
+		Notifier:  notifier,
+		StatePath: filepath.Join(env.dataDir, pomodoro.StateFileName),
+	})
+
+	if err := app.Run(); err != nil {
+		return fmt.Errorf("failed to run TUI: %w", err)
+	}
+	return nil
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// retentionPolicy converts the backup config into a storage retention policy
+func retentionPolicy(cfg *config.Config) storage.RetentionPolicy {
+	return storage.RetentionPolicy{
+		KeepDaily:   cfg.Backup.KeepDaily,
+		KeepWeekly:  cfg.Backup.KeepWeekly,
+		KeepMonthly: cfg.Backup.KeepMonthly,
+	}
+}
+
 // getDataDir returns the data directory for the application
 func getDataDir() (string, error) {
 	// Try XDG_DATA_HOME first
@@ -510,58 +1600,22 @@ func getDataDir() (string, error) {
 	return filepath.Join(homeDir, ".local", "share", appName), nil
 }
 
-// showHelp displays usage information
-func showHelp() {
-	fmt.Printf(`%s
-
-%s %s - Canonical Hours Task Manager
-
-USAGE:
-    %s <command> [arguments]
-
-COMMANDS:
-    add <title> [description] [work] [play] [learn]
-        Create a new task with optional scores (0-5)
-        Example: %s add "Fix API bug" "Memory leak" 4 1 3
-
-    list
-        Show all tasks with their status and scores
-
-    complete <number>
-        Mark a task as completed (use task number from list)
-
-    delete <number>
-        Delete a task (use task number from list)
-
-    status
-        Show current canonical hour and task summary
-
-    schedule
-        Display the canonical hours schedule
-
-    stats
-        Show today's productivity statistics
-
-    backup
-        Create a backup of all data
-
-    data-dir
-        Show data directory location
-
-    version
-        Show version information
-
-    help
-        Show this help message
-
-EXAMPLES:
-    %s add "Review code" "PR #123" 5 2 3
-    %s list
-    %s complete              # Interactive selection
-    %s complete bug          # Complete task matching "bug"
-    %s complete 1            # Complete task #1
-    %s delete old            # Delete task matching "old"
-    %s status
+// helpFooter follows the generated command list in the help, with the
+// program name for %[1]s
+const helpFooter = `EXAMPLES:
+    %[1]s add "Review code" "PR #123" 5 2 3
+    %[1]s add "Fix API bug" --work 4 --tags api,bug --hour Prime --due tomorrow
+    %[1]s list
+    %[1]s complete              # Interactive selection
+    %[1]s complete bug          # Complete task matching "bug"
+    %[1]s complete 1            # Complete task #1
+    %[1]s delete old            # Delete task matching "old"
+    %[1]s edit 1 --due fri      # Change a task's fields
+    %[1]s history 1             # See how task #1 changed over time
+    %[1]s start bug             # Start tracking time on task matching "bug"
+    %[1]s pause                 # Pause the running task
+    %[1]s log bug 14:00-15:30   # Record time you forgot to track
+    %[1]s status
 
 CANONICAL HOURS:
     Matins    06:00-07:30  Deep work, planning
@@ -581,8 +1635,12 @@ SCORING SYSTEM:
 
 DATA LOCATION:
     Linux/macOS: ~/.local/share/qomoboro/
-    Or: $XDG_DATA_HOME/qomoboro/ if XDG_DATA_HOME is set
+    Or: $XDG_DATA_HOME/qomoboro/ if XDG_DATA_HOME is set, or --data-dir
 
-For more information, visit: https://github.com/QRY91/qomoboro
-`, ascii, appName, version, appName, appName, appName, appName, appName, appName)
-}
+CONFIGURATION:
+    ~/.config/qomoboro/config.json (or $XDG_CONFIG_HOME/qomoboro/)
+    {"storage": {"backend": "sqlite"}} stores data in qomoboro.db
+    {"backup": {"auto": true, "keep_daily": 7, "keep_weekly": 4, "keep_monthly": 6}}
+    {"ui": {"tui_by_default": true}} opens the TUI when run without a command
+
+For more information, visit: https://github.com/QRY91/qomoboro`
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"qomoboro/internal/cli"
+	"qomoboro/internal/models"
+	"qomoboro/internal/output"
+	"qomoboro/internal/storage"
+)
+
+// showCommand shows everything recorded about one task
+func showCommand(env *cliEnv) *cli.Command {
+	var format output.Format
+	cmd := &cli.Command{
+		Name:    "show",
+		Aliases: []string{"info"},
+		Args:    "[id|title]",
+		Summary: "Show every detail of a task, with its tracked time",
+		Examples: []string{
+			"show 12",
+			"show bug -o json",
+		},
+		Run: func(args []string) error { return handleShowTask(env.store, format, args) },
+	}
+	outputFlag(cmd.Flags(), &format)
+	return cmd
+}
+
+// taskReport is the result of show in machine-readable formats
+type taskReport struct {
+	Task    *models.Task        `json:"task" yaml:"task"`
+	Tracked time.Duration       `json:"tracked" yaml:"tracked"` // Including the running time entry
+	Overdue bool                `json:"overdue" yaml:"overdue"`
+	Events  []*models.TaskEvent `json:"events" yaml:"events"` // Recorded changes, oldest first
+}
+
+// newTaskReport describes task as of now, with its recorded changes
+func newTaskReport(task *models.Task, events []*models.TaskEvent, now time.Time) *taskReport {
+	if events == nil {
+		events = []*models.TaskEvent{}
+	}
+	return &taskReport{Task: task, Tracked: task.Elapsed(now), Overdue: task.IsOverdue(now), Events: events}
+}
+
+func handleShowTask(store storage.Storage, format output.Format, args []string) error {
+	tasks, err := store.ListTasks()
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+	if len(tasks) == 0 {
+		fmt.Println("No tasks yet. Create one with: qomoboro add \"Task title\"")
+		return nil
+	}
+	task, err := selectTask(tasks, args, "", "show")
+	if err != nil {
+		return err
+	}
+
+	events, err := store.ListEvents(storage.EventFilter{TaskID: task.ID})
+	if err != nil {
+		return fmt.Errorf("failed to load task history: %w", err)
+	}
+
+	now := time.Now()
+	if !format.IsText() {
+		return output.Write(os.Stdout, format, newTaskReport(task, events, now), taskTable([]*models.Task{task}))
+	}
+	printTask(task, now)
+
+	if len(events) > 0 {
+		fmt.Printf("\n📜 History (%d):\n", len(events))
+		for _, event := range events {
+			printEvent(event, "   ")
+		}
+	}
+	return nil
+}
+
+// printTask writes every field of task that is set
+func printTask(task *models.Task, now time.Time) {
+	fmt.Printf("%s %s %s\n", task.Ref(), getStatusEmoji(task.Status), task.Title)
+	if task.Description != "" {
+		fmt.Printf("   %s\n", colorize(task.Description, "dim"))
+	}
+	fmt.Println(strings.Repeat("─", 60))
+
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Printf("%-12s %s\n", label+":", value)
+		}
+	}
+	field("Status", task.Status.String())
+	field("Scores", fmt.Sprintf("Work %d, Play %d, Learn %d (total %d)",
+		task.Score.Work, task.Score.Play, task.Score.Learn, task.Score.Total()))
+	if len(task.Tags) > 0 {
+		field("Tags", "#"+strings.Join(task.Tags, " #"))
+	}
+	field("Hour", task.CanonicalHour)
+	if task.DueDate != nil {
+		due := task.DueDate.Format("Mon Jan 2, 2006")
+		if task.IsOverdue(now) {
+			due += " (overdue)"
+		}
+		field("Due", due)
+	}
+	field("Scheduled", formatLocalTime(task.ScheduledTime))
+
+	tracked := task.Elapsed(now)
+	if task.EstimatedDuration > 0 {
+		field("Estimate", formatDuration(task.EstimatedDuration))
+	}
+	if tracked > 0 || task.EstimatedDuration > 0 {
+		actual := formatDuration(tracked)
+		if task.Status == models.TaskStatusActive {
+			actual += " so far"
+		}
+		if task.EstimatedDuration > 0 {
+			actual += colorize(fmt.Sprintf(" (%d%% of estimate)", int(100*tracked/task.EstimatedDuration)), "dim")
+		}
+		field("Tracked", actual)
+	}
+	if task.Pomodoros > 0 {
+		field("Pomodoros", fmt.Sprint(task.Pomodoros))
+	}
+
+	field("Created", formatLocalTime(&task.CreatedAt))
+	field("Updated", formatLocalTime(&task.UpdatedAt))
+	field("Started", formatLocalTime(task.StartTime))
+	field("Ended", formatLocalTime(task.EndTime))
+	field("Completed", formatLocalTime(task.CompletedAt))
+	field("ID", colorize(task.ID, "dim"))
+
+	for _, text := range []struct{ label, value string }{
+		{"Notes", task.Notes},
+		{"Reflection", task.Reflection},
+	} {
+		if text.value == "" {
+			continue
+		}
+		fmt.Printf("\n%s:\n", text.label)
+		for _, line := range strings.Split(strings.TrimRight(text.value, "\n"), "\n") {
+			fmt.Printf("   %s\n", line)
+		}
+	}
+
+	if len(task.TimeEntries) > 0 {
+		fmt.Printf("\n⏱️  Time entries (%d):\n", len(task.TimeEntries))
+		for _, entry := range task.TimeEntries {
+			line := fmt.Sprintf("   %s  %-11s %7s", entry.Start.Local().Format("Mon Jan 2"), entry.String(), formatDuration(entry.Duration(now)))
+			if entry.CanonicalHour != "" {
+				line += "  " + entry.CanonicalHour
+			}
+			if entry.Note != "" {
+				line += "  " + colorize(entry.Note, "dim")
+			}
+			fmt.Println(line)
+		}
+	}
+
+	if len(task.Interruptions) > 0 {
+		fmt.Printf("\n🔔 Interruptions (%d):\n", len(task.Interruptions))
+		for _, interruption := range task.Interruptions {
+			line := fmt.Sprintf("   %s  %-8s round %d", interruption.At.Local().Format("Mon Jan 2 15:04"), interruption.Kind, interruption.Round)
+			if interruption.CanonicalHour != "" {
+				line += "  " + interruption.CanonicalHour
+			}
+			if interruption.Reason != "" {
+				line += "  " + colorize(interruption.Reason, "dim")
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
+// formatLocalTime renders t in local time, or "" when it is nil
+func formatLocalTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("Mon Jan 2, 2006 15:04")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"qomoboro/internal/models"
+)
+
+func TestTaskReport_JSON(t *testing.T) {
+	now := time.Date(2024, 3, 6, 15, 0, 0, 0, time.UTC)
+	start := now.Add(-time.Hour)
+	due := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC) // The day before
+	task := &models.Task{
+		ID: "task_a", ShortID: 1, Title: "Fix bug", Status: models.TaskStatusPaused,
+		DueDate:     &due,
+		TimeEntries: []models.TimeEntry{{Start: start, End: &now}},
+		CreatedAt:   start, UpdatedAt: now,
+	}
+	event := &models.TaskEvent{
+		At: now, Action: models.EventUpdate, TaskID: "task_a", ShortID: 1, Title: "Fix bug", Source: "cli pause",
+		Changes: []models.FieldChange{{Field: "status", Old: float64(models.TaskStatusActive), New: float64(models.TaskStatusPaused)}},
+	}
+
+	tests := []struct {
+		name   string
+		events []*models.TaskEvent
+		want   int
+	}{
+		{name: "with history", events: []*models.TaskEvent{event}, want: 1},
+		{name: "without history", events: nil, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(newTaskReport(task, tt.events, now))
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			var doc map[string]json.RawMessage
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+
+			var keys []string
+			for key := range doc {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if want := []string{"events", "overdue", "task", "tracked"}; !reflect.DeepEqual(keys, want) {
+				t.Errorf("report keys = %v, want %v", keys, want)
+			}
+
+			var tracked time.Duration
+			var overdue bool
+			json.Unmarshal(doc["tracked"], &tracked)
+			json.Unmarshal(doc["overdue"], &overdue)
+			if tracked != time.Hour || !overdue {
+				t.Errorf("report = tracked %v, overdue %v, want 1h, true", tracked, overdue)
+			}
+
+			// An empty history is an empty list rather than null
+			var events []map[string]any
+			if err := json.Unmarshal(doc["events"], &events); err != nil || events == nil {
+				t.Fatalf("report events = %s, want a list", doc["events"])
+			}
+			if len(events) != tt.want {
+				t.Fatalf("report has %d events, want %d", len(events), tt.want)
+			}
+			if tt.want > 0 && (events[0]["action"] != "update" || events[0]["source"] != "cli pause") {
+				t.Errorf("report event = %v, want the update from cli pause", events[0])
+			}
+		})
+	}
+}